@@ -0,0 +1,355 @@
+package main
+
+import (
+	"kleio/cmd/crawler"
+	"kleio/cmd/database"
+	"kleio/pkg/config"
+	"kleio/pkg/git"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// stringsFlag is a flag that can be repeated multiple times
+type stringsFlag []string
+
+func (s *stringsFlag) String() string {
+	return strings.Join(*s, ",")
+}
+
+func (s *stringsFlag) Set(value string) error {
+	*s = append(*s, value)
+	return nil
+}
+
+// flagSet is a [flag.FlagSet] whose flags can override the configuration values used by kleio
+type flagSet struct {
+	*flag.FlagSet
+	config string
+	envs   map[string]*string
+}
+
+// newFlagSet returns a [flagSet] for the given subcommand, with one flag for each of the given configuration values
+// (identified by the name of the environment variable overriding them)
+func newFlagSet(name string, envs ...string) *flagSet {
+	fs := &flagSet{FlagSet: flag.NewFlagSet(name, flag.ExitOnError), envs: map[string]*string{}}
+	fs.StringVar(&fs.config, "config", "", "path of the YAML configuration file")
+
+	for _, setting := range config.Settings {
+		if slices.Contains(envs, setting.Env) {
+			flagName := strings.ToLower(strings.ReplaceAll(setting.Env, "_", "-"))
+			fs.envs[setting.Env] = fs.String(flagName, "", setting.Usage+" (overrides $"+setting.Env+")")
+		}
+	}
+
+	return fs
+}
+
+// parse parses the arguments and returns the configuration (loaded from the configuration file, the environment
+// variables, and the flags, in increasing order of precedence), validated against needs
+func (fs *flagSet) parse(args []string, needs ...config.Need) (*config.Config, error) {
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if fs.NArg() > 0 {
+		return nil, fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	cfg, err := config.Load(fs.config)
+
+	if err != nil {
+		return nil, err
+	}
+
+	for _, env := range slices.Sorted(maps.Keys(fs.envs)) {
+		if value := *fs.envs[env]; value != "" {
+			if err = cfg.Set(env, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err = cfg.Validate(needs...); err != nil {
+		return nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+
+	return cfg, nil
+}
+
+// allSettings returns the names of all the configuration values that can be overridden
+func allSettings() []string {
+	envs := []string{}
+
+	for _, setting := range config.Settings {
+		envs = append(envs, setting.Env)
+	}
+
+	return envs
+}
+
+func runDiscover(args []string) error {
+	fs := newFlagSet("discover", "GITHUB_PAT", "SIZE", "PAGES")
+	output := fs.String("output", "./repositories.txt", "file where the repository URLs are saved")
+
+	cfg, err := fs.parse(args, config.NeedGitHub, config.NeedSearch)
+
+	if err != nil {
+		return err
+	}
+
+	return crawler.Discover(cfg, *output)
+}
+
+func runCrawl(args []string) error {
+	fs := newFlagSet("crawl", allSettings()...)
+	repos := fs.String("repos", "./repositories.txt", "file containing the URLs of the repositories to crawl")
+	skipActions := fs.Bool("skip-actions", false, "do not resolve the Actions used by the workflows")
+	restart := fs.Bool("restart", false, "crawl again the repositories already persisted in a previous run")
+	incremental := fs.Bool("incremental", false, "only add the workflow commits not already saved")
+
+	cfg, err := fs.parse(args, config.NeedNeo, config.NeedMongo, config.NeedGitHub, config.NeedCrawl, config.NeedVulns)
+
+	if err != nil {
+		return err
+	}
+
+	if _, err = os.Stat(*repos); os.IsNotExist(err) {
+		if err = cfg.Validate(config.NeedSearch); err != nil {
+			return err
+		}
+	}
+
+	neoDriver, neoCtx, mongoClient := crawler.Initialize(cfg, *repos)
+	crawler.ExtractWorkflows(cfg, *repos, !*skipActions, !*restart, *incremental, neoDriver, neoCtx, mongoClient)
+
+	git.DeleteRepo("../tmp")
+
+	fmt.Println("All Done")
+
+	return nil
+}
+
+func runResolveActions(args []string) error {
+	var repos, actions stringsFlag
+
+	fs := newFlagSet(
+		"resolve-actions",
+		"NEO_URI", "NEO_USER", "NEO_PASS", "MONGO_URI", "MONGO_USER", "MONGO_PASS", "GITHUB_PAT", "GITHUB_PAT_VULN",
+		"OSV_DIR", "VULN_SOURCES", "VULN_ADVISORIES_DIR",
+	)
+	fs.Var(&repos, "repo", "full name of a saved repository whose Actions are resolved (repeatable, default all)")
+	fs.Var(&actions, "action", "full name of an Action to resolve (repeatable, default the ones used by -repo)")
+	force := fs.Bool("force", false, "resolve Actions already persisted again")
+
+	cfg, err := fs.parse(args, config.NeedNeo, config.NeedMongo, config.NeedGitHub, config.NeedVulns)
+
+	if err != nil {
+		return err
+	}
+
+	driver, ctx, client, err := crawler.Connect(cfg)
+
+	if err != nil {
+		return err
+	}
+
+	defer driver.Close(ctx)
+
+	crawler.ResolveActions(cfg, repos, actions, *force, driver, ctx, client)
+
+	return nil
+}
+
+func runDiff(args []string) error {
+	var repos stringsFlag
+
+	fs := newFlagSet("diff", "NEO_URI", "NEO_USER", "NEO_PASS", "MONGO_URI", "MONGO_USER", "MONGO_PASS")
+	fs.Var(&repos, "repo", "full name of a saved repository whose diffs are recomputed (repeatable, default all)")
+
+	cfg, err := fs.parse(args, config.NeedNeo, config.NeedMongo)
+
+	if err != nil {
+		return err
+	}
+
+	driver, ctx, client, err := crawler.Connect(cfg)
+
+	if err != nil {
+		return err
+	}
+
+	defer driver.Close(ctx)
+
+	crawler.DiffWorkflows(repos, driver, ctx, client)
+
+	return nil
+}
+
+func runExport(args []string) error {
+	var repos stringsFlag
+
+	fs := newFlagSet("export", "NEO_URI", "NEO_USER", "NEO_PASS")
+	fs.Var(&repos, "repo", "full name of a saved repository to export (repeatable, default all)")
+	output := fs.String("output", "./export.json", "file where the JSON export is saved")
+
+	cfg, err := fs.parse(args, config.NeedNeo)
+
+	if err != nil {
+		return err
+	}
+
+	driver, ctx, err := database.ConnectToNeo(cfg.Neo)
+
+	if err != nil {
+		return err
+	}
+
+	defer driver.Close(ctx)
+
+	f, err := os.Create(*output)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	exported := []database.ExportedRepository{}
+
+	for _, repo := range database.GetRepositories(repos, driver, ctx) {
+		exported = append(exported, database.ExportRepository(repo, driver, ctx))
+	}
+
+	encoder := json.NewEncoder(f)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(exported)
+}
+
+func runReport(args []string) error {
+	var repos stringsFlag
+
+	fs := newFlagSet("report", "NEO_URI", "NEO_USER", "NEO_PASS")
+	fs.Var(&repos, "repo", "full name of a saved repository to include in the report (repeatable, default all)")
+	permissions := fs.Bool("permissions", false, "report when the GITHUB_TOKEN privileges were widened or narrowed")
+
+	cfg, err := fs.parse(args, config.NeedNeo)
+
+	if err != nil {
+		return err
+	}
+
+	driver, ctx, err := database.ConnectToNeo(cfg.Neo)
+
+	if err != nil {
+		return err
+	}
+
+	defer driver.Close(ctx)
+
+	if *permissions {
+		printPermissionChanges(database.GetPermissionChanges(repos, driver, ctx))
+
+		return nil
+	}
+
+	report := database.GetReport(repos, driver, ctx)
+
+	fmt.Println()
+	fmt.Printf("%-24s %d\n", "Repositories", report.Repositories)
+	fmt.Printf("%-24s %d\n", "Workflows", report.Workflows)
+	fmt.Printf("%-24s %d\n", "Workflow commits", report.Commits)
+
+	fmt.Println("\nUses by version type")
+
+	for _, typz := range slices.Sorted(maps.Keys(report.Uses)) {
+		fmt.Printf("  %-22s %d\n", typz, report.Uses[typz])
+	}
+
+	fmt.Println("\nComponents by type")
+
+	for _, typz := range slices.Sorted(maps.Keys(report.Components)) {
+		fmt.Printf("  %-22s %d\n", typz, report.Components[typz])
+	}
+
+	return nil
+}
+
+func runVulndb(args []string) error {
+	var imports stringsFlag
+
+	fs := newFlagSet(
+		"vulndb",
+		"NEO_URI", "NEO_USER", "NEO_PASS", "GITHUB_PAT", "GITHUB_PAT_VULN", "OSV_DIR", "OSV_URL", "OSV_ECOSYSTEMS",
+		"VULN_SOURCES", "VULN_ADVISORIES_DIR",
+	)
+	fs.Var(&imports, "import", "data dump to import instead of downloading it, as ecosystem=path (repeatable)")
+	offline := fs.Bool("offline", false, "do not download the data dumps, only import the given ones and index the stored ones")
+	enrich := fs.Bool("enrich", false, "match the saved packages, Actions, and container images against the sources of vulnerabilities")
+
+	cfg, err := fs.parse(args, config.NeedOSV)
+
+	if err != nil {
+		return err
+	}
+
+	if *enrich {
+		if err = cfg.Validate(config.NeedNeo, config.NeedVulns); err != nil {
+			return fmt.Errorf("invalid configuration:\n%w", err)
+		}
+	}
+
+	if err = crawler.RefreshVulnerabilities(cfg, imports, *offline); err != nil {
+		return err
+	}
+
+	if !*enrich {
+		return nil
+	}
+
+	driver, ctx, err := database.ConnectToNeo(cfg.Neo)
+
+	if err != nil {
+		return err
+	}
+
+	defer driver.Close(ctx)
+
+	crawler.EnrichVulnerabilities(cfg, driver, ctx)
+
+	return nil
+}
+
+// printPermissionChanges prints the changes of the GITHUB_TOKEN privileges, grouped by repository
+func printPermissionChanges(changes []database.PermissionChange) {
+	repository := ""
+
+	for _, change := range changes {
+		if change.Repository != repository {
+			repository = change.Repository
+			fmt.Printf("\n\u001B[31m%s\u001B[0m\n", repository)
+		}
+
+		direction := "\u001B[32mnarrowed\u001B[0m"
+
+		if change.Widened {
+			direction = "\u001B[31mwidened\u001B[0m "
+		}
+
+		fmt.Printf(
+			"  %s  %-40s %.7s  %s  %-20s %s -> %s\n",
+			change.Date.Format("2006-01-02 15:04"),
+			strings.TrimPrefix(change.Workflow, repository+"/"),
+			change.Commit, direction, change.Scope, change.Old, change.New,
+		)
+	}
+
+	if len(changes) == 0 {
+		fmt.Println("\nNo changes of the GITHUB_TOKEN privileges found")
+	}
+}
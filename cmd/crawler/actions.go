@@ -0,0 +1,54 @@
+package crawler
+
+import (
+	"kleio/cmd/database"
+	"kleio/pkg/config"
+	"kleio/pkg/git"
+	"kleio/pkg/github"
+	"kleio/pkg/vulns"
+	"context"
+	"fmt"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+)
+
+// ResolveActions resolves the versions and commits of the given Actions. If no Action is given, the Actions used by
+// the workflows of the given repositories (or of all the repositories if none is given) saved in neo4j are resolved
+func ResolveActions(cfg *config.Config, repos []string, actions []string, force bool, driver neo4j.DriverWithContext, ctx context.Context, client mongo.Database) {
+	database.MigrateComponents(driver, ctx)
+
+	if len(actions) == 0 {
+		for _, repo := range database.GetRepositories(repos, driver, ctx) {
+			for _, content := range database.GetWorkflowContents(repo, driver, ctx) {
+				components, err := git.ExtractComponents(content)
+
+				if err != nil || components == nil {
+					continue
+				}
+
+				for _, component := range components {
+					if component.GetCategory() == "action" {
+						actions = append(actions, component.GetName())
+					}
+				}
+			}
+		}
+	}
+
+	fmt.Printf("\u001B[37m[ACTIONS]\u001B[0m Resolving \u001B[34m%d\u001B[0m Action references\n", len(actions))
+
+	github.ResolveActions(actions, force, cfg, github.NewClient(cfg.GitHub), database.NewLedger(client), database.NewBindings(client), vulns.New(cfg), driver, ctx)
+}
+
+// DiffWorkflows recomputes the syntactical diffs between the commits of the workflows of the given repositories (or
+// of all the repositories if none is given)
+func DiffWorkflows(repos []string, driver neo4j.DriverWithContext, ctx context.Context, client mongo.Database) {
+	for _, repo := range database.GetRepositories(repos, driver, ctx) {
+		fmt.Println("\u001B[37m[DIFF]\u001B[0m Computing diffs of \u001B[31m" + repo + "\u001B[0m")
+
+		for _, workflow := range database.GetWorkflows(repo, driver, ctx) {
+			database.DiffWorkflow(workflow, nil, driver, ctx, client)
+		}
+	}
+}
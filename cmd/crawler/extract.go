@@ -2,64 +2,234 @@ package crawler
 
 import (
 	"kleio/cmd/database"
+	"kleio/pkg/config"
 	"kleio/pkg/git"
 	"kleio/pkg/git/model"
 	"kleio/pkg/github"
-	"bufio"
+	"kleio/pkg/vulns"
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
-// ExtractWorkflows extracts the workflows from the Repository
-func ExtractWorkflows(neoDriver neo4j.DriverWithContext, neoCtx context.Context, mongoClient mongo.Database) {
-	f, err := os.Open("./repositories.txt")
+// The name of the incremental pass in the ledger
+const passIncremental = "incremental"
+
+// An extraction is the result of extracting the workflows of a repository
+type extraction struct {
+	url       string
+	workflows []model.File
+	saved     map[string]map[string]bool
+	log       *bytes.Buffer
+	err       error
+}
+
+// extractRepository clones and extracts the workflows of a repository (only their commits not in saved), recording its
+// progress in the ledger and deleting the clone afterward
+func extractRepository(url string, cfg *config.Config, ledger *database.Ledger, saved map[string]map[string]bool, out io.Writer) (workflows []model.File, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+
+	repoPath, err := git.CloneRepository(url, cfg, out)
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Clones are deleted whether the extraction succeeds or not, while the repositories of REPOS_DIR are kept
+	if strings.HasPrefix(url, "http") {
+		defer git.DeleteRepo(repoPath)
+	}
+
+	ledger.Mark(database.KindRepository, url, database.StateCloned)
+
+	workflows, err = git.ExtractWorkflows(repoPath, saved, out)
+
+	if err != nil {
+		return nil, err
+	}
+
+	ledger.Mark(database.KindRepository, url, database.StateExtracted)
+
+	return workflows, nil
+}
+
+// extractRepositories clones and extracts the workflows of the repositories with a pool of workers, and sends the
+// results on the returned channel. With more than one worker, the progress messages of each repository are buffered
+// and sent together with its result, so that the messages of different repositories do not interleave. The hashes of
+// the commits already saved for the workflows of each repository are looked up in saved
+func extractRepositories(cfg *config.Config, repositories []string, ledger *database.Ledger, saved map[string]map[string]map[string]bool) <-chan extraction {
+	jobs := make(chan string)
+	results := make(chan extraction, cfg.Crawl.Workers)
+
+	var wg sync.WaitGroup
+
+	for range cfg.Crawl.Workers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for url := range jobs {
+				var out io.Writer = os.Stdout
+				buffer := &bytes.Buffer{}
+
+				if cfg.Crawl.Workers > 1 {
+					out = buffer
+				}
+
+				workflows, err := extractRepository(url, cfg, ledger, saved[url], out)
+				results <- extraction{url: url, workflows: workflows, saved: saved[url], log: buffer, err: err}
+			}
+		}()
+	}
+
+	go func() {
+		for _, url := range repositories {
+			jobs <- url
+		}
+
+		close(jobs)
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
+
+// ExtractWorkflows extracts the workflows from the Repository. Repositories are cloned and extracted concurrently,
+// while the Actions' resolution and the writes to the databases are serialised. If resume is set, the repositories
+// already persisted according to the ledger are skipped. If incremental is set, all the repositories are crawled
+// again, but only the workflow commits not saved yet (and their diffs) are added. Both can be set: an incremental crawl
+// is a pass recorded in the ledger, and resuming it skips the repositories already persisted during that pass
+func ExtractWorkflows(cfg *config.Config, reposPath string, resolveActions bool, resume bool, incremental bool, neoDriver neo4j.DriverWithContext, neoCtx context.Context, mongoClient mongo.Database) {
+	allRepositories, err := ReadRepositories(reposPath)
 
 	if err != nil {
 		panic(err)
 	}
 
+	ledger := database.NewLedger(mongoClient)
+	client := github.NewClient(cfg.GitHub)
+	bindings := database.NewBindings(mongoClient)
+	sources := vulns.New(cfg)
 	repositories := []string{}
-	scanner := bufio.NewScanner(f)
+	saved := map[string]map[string]map[string]bool{}
+
+	var pass time.Time
+
+	if incremental {
+		pass = startPass(ledger, resume)
+	}
+
+	for _, url := range allRepositories {
+		if incremental {
+			if resume && ledger.IsDoneSince(database.KindRepository, url, pass) {
+				continue
+			}
+
+			saved[url] = database.GetSavedCommits(strings.TrimPrefix(url, "https://github.com/"), neoDriver, neoCtx)
+		} else if resume && ledger.IsDone(database.KindRepository, url) {
+			continue
+		}
+
+		if resume && !incremental {
+			ledger.Queue(database.KindRepository, url)
+		} else {
+			ledger.Mark(database.KindRepository, url, database.StateQueued)
+		}
+
+		repositories = append(repositories, url)
+	}
 
-	for scanner.Scan() {
-		repositories = append(repositories, scanner.Text())
+	if skipped := len(allRepositories) - len(repositories); skipped > 0 {
+		fmt.Printf("\u001B[37m[CRAWL]\u001B[0m Skipping \u001B[34m%d\u001B[0m repositories already persisted\n\n", skipped)
 	}
 
-	// progressBar := progress.NewPBar()
-	// progressBar.Total = uint16(len(repositories))
+	done := 0
 
-	for _, url := range repositories {
-		// progressBar.RenderPBar(index)
+	for result := range extractRepositories(cfg, repositories, ledger, saved) {
+		done++
 
-		// Extract all workflows from repository
-		workflows, err := git.ExtractWorkflows(url)
+		_, _ = io.Copy(os.Stdout, result.log)
 
-		if err != nil {
-			urlSplit := strings.Split(url, "/")
-			git.DeleteRepo(urlSplit[len(urlSplit)-1])
+		fmt.Printf(
+			"\u001B[37m[CRAWL]\u001B[0m Extracted \u001B[31m%s\u001B[0m [%d/%d]\n",
+			result.url, done, len(repositories),
+		)
 
-			if strings.Contains(err.Error(), "no such file") {
+		if result.err != nil {
+			if errors.Is(result.err, git.ErrNoWorkflows) {
 				fmt.Println(" \u001B[31m𐄂\u001B[0m \u001B[34m(No workflows found)\u001B[0m")
-				fmt.Println()
+
+				// There is nothing left to do for repositories without workflows
+				ledger.Mark(database.KindRepository, result.url, database.StatePersisted)
+			} else {
+				fmt.Println(" \u001B[31m𐄂\u001B[0m \u001B[34m(" + result.err.Error() + ")\u001B[0m")
+				ledger.Fail(database.KindRepository, result.url, result.err)
 			}
 
+			fmt.Println()
+
 			continue
 		}
 
-		var repo model.Repository
-		repo.Init(strings.TrimPrefix(url, "https://github.com/"), url, workflows)
+		persistRepository(cfg, result, resolveActions, client, ledger, bindings, sources, neoDriver, neoCtx, mongoClient)
+	}
+
+	if incremental {
+		ledger.Mark(database.KindPass, passIncremental, database.StatePersisted)
+	}
+}
+
+// startPass returns the start of the incremental pass of the crawl. If resume is set and the previous pass was
+// interrupted, that pass is resumed, otherwise a new one is started
+func startPass(ledger *database.Ledger, resume bool) time.Time {
+	if checkpoint, found := ledger.Get(database.KindPass, passIncremental); resume && found && checkpoint.State != database.StatePersisted {
+		fmt.Printf("\u001B[37m[CRAWL]\u001B[0m Resuming the incremental crawl started at \u001B[34m%s\u001B[0m\n\n", checkpoint.Updated.Format(time.RFC3339))
+
+		return checkpoint.Updated
+	}
+
+	start := time.Now().UTC()
+	ledger.Mark(database.KindPass, passIncremental, database.StateQueued)
+
+	return start
+}
+
+
+// persistRepository resolves the Actions of an extracted repository and saves it to the databases. Panics are
+// recorded as failures in the ledger, so that the crawl can continue with the next repository
+func persistRepository(cfg *config.Config, result extraction, resolveActions bool, client *github.Client, ledger *database.Ledger, bindings *database.Bindings, sources vulns.Sources, neoDriver neo4j.DriverWithContext, neoCtx context.Context, mongoClient mongo.Database) {
+	defer func() {
+		if r := recover(); r != nil {
+			fmt.Printf(" \u001B[31m𐄂\u001B[0m \u001B[34m(%v)\u001B[0m\n\n", r)
+			ledger.Fail(database.KindRepository, result.url, r)
+		}
+	}()
 
-		// Retrieve Actions Commits
-		github.GetActionsCommits(repo, neoDriver, neoCtx)
+	var repo model.Repository
+	repo.Init(strings.TrimPrefix(result.url, "https://github.com/"), result.url, result.workflows)
 
-		// Save repo to databases
-		database.SendToDB(repo, neoDriver, neoCtx, mongoClient)
+	// Retrieve Actions Commits
+	if resolveActions {
+		github.GetActionsCommits(repo, false, cfg, client, ledger, bindings, sources, neoDriver, neoCtx)
+		ledger.Mark(database.KindRepository, result.url, database.StateResolved)
 	}
 
-	// progressBar.CleanUp()
+	// Save repo to databases
+	database.SendToDB(repo, result.saved, neoDriver, neoCtx, mongoClient)
+	ledger.Mark(database.KindRepository, result.url, database.StatePersisted)
 }
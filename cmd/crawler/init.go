@@ -2,6 +2,7 @@ package crawler
 
 import (
 	"kleio/cmd/database"
+	"kleio/pkg/config"
 	"context"
 	"fmt"
 	"os"
@@ -10,29 +11,40 @@ import (
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
-// Initialize initializes the configuration file, db, and repositories' URLs
-func Initialize() (neo4j.DriverWithContext, context.Context, mongo.Database) {
-	fmt.Println("\u001B[37m[INIT]\u001B[0m \u001B[33mStarting initialization step")
+// Connect connects to the Neo4j and MongoDB instances
+func Connect(cfg *config.Config) (neo4j.DriverWithContext, context.Context, mongo.Database, error) {
+	neoDriver, neoCtx, err := database.ConnectToNeo(cfg.Neo)
 
-	// Connect to DBs
-	neoDriver, neoCtx, err := database.ConnectToNeo()
+	if err != nil {
+		return nil, nil, mongo.Database{}, err
+	}
+
+	mongoClient, err := database.ConnectionToMongo(cfg.Mongo)
 
 	if err != nil {
-		panic(err)
+		return nil, nil, mongo.Database{}, err
 	}
 
-	// Connect to MongoDB
-	mongoClient, err := database.ConnectionToMongo()
+	return neoDriver, neoCtx, mongoClient, nil
+}
+
+// Initialize initializes the configuration file, db, and repositories' URLs
+func Initialize(cfg *config.Config, reposPath string) (neo4j.DriverWithContext, context.Context, mongo.Database) {
+	fmt.Println("\u001B[37m[INIT]\u001B[0m \u001B[33mStarting initialization step")
+
+	// Connect to DBs
+	neoDriver, neoCtx, mongoClient, err := Connect(cfg)
 
 	if err != nil {
 		panic(err)
 	}
 
-	// Retrieve top N URLs from GitHub (if file does not exist)
-	reposPath := "./repositories.txt"
+	database.MigrateComponents(neoDriver, neoCtx)
+	database.MigrateVulnerabilities(neoDriver, neoCtx)
 
+	// Retrieve top N URLs from GitHub (if file does not exist)
 	if _, err = os.Stat(reposPath); os.IsNotExist(err) {
-		if err = getTopRepositories(); err != nil {
+		if err = getTopRepositories(cfg.GitHub, reposPath); err != nil {
 			panic(err)
 		}
 	}
@@ -1,11 +1,14 @@
 package crawler
 
 import (
+	"kleio/pkg/config"
 	"kleio/pkg/github"
-	"encoding/json"
+	"bufio"
+	"context"
 	"fmt"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gosuri/uilive"
@@ -18,8 +21,8 @@ type Repos struct {
 }
 
 // writeToFile takes all the retrieved URLs and saves them in a file
-func writeToFile(urls []string) error {
-	file, err := os.Create("./repositories.txt")
+func writeToFile(urls []string, output string) error {
+	file, err := os.Create(output)
 
 	if err != nil {
 		return err
@@ -42,20 +45,13 @@ func writeToFile(urls []string) error {
 	return nil
 }
 
-// GetTopRepositories saves all retrieved top repository URLs to a file
-func getTopRepositories() error {
+// getTopRepositories saves all retrieved top repository URLs to a file
+func getTopRepositories(cfg config.GitHub, output string) error {
 	var urls []string
 
-	ghToken := os.Getenv("GITHUB_PAT")
-	ghPageSizeRaw := os.Getenv("SIZE")
-	ghPagesRaw := os.Getenv("PAGES")
-
-	ghPageSize, err := strconv.Atoi(ghPageSizeRaw)
-	ghPages, err := strconv.Atoi(ghPagesRaw)
-
-	if err != nil {
-		return err
-	}
+	client := github.NewClient(cfg)
+	ghPageSize := cfg.PageSize
+	ghPages := cfg.Pages
 
 	writer := uilive.New()
 	writer.Start()
@@ -69,14 +65,8 @@ func getTopRepositories() error {
 			strconv.Itoa(page+1),
 		)
 
-		res, _, err := github.PerformApiCall(url, ghToken, nil)
-
-		if err != nil {
-			return err
-		}
-
 		var repos Repos
-		err = json.NewDecoder(res).Decode(&repos)
+		err := client.GetJSON(context.Background(), url, &repos)
 
 		if err != nil {
 			return err
@@ -103,7 +93,7 @@ func getTopRepositories() error {
 
 	writer.Stop()
 
-	err = writeToFile(urls)
+	err := writeToFile(urls, output)
 
 	if err != nil {
 		return err
@@ -111,3 +101,38 @@ func getTopRepositories() error {
 
 	return nil
 }
+
+// Discover retrieves the top repositories from GitHub and saves their URLs in the output file
+func Discover(cfg *config.Config, output string) error {
+	fmt.Println("\u001B[37m[INIT]\u001B[0m \u001B[33mStarting discovery step")
+
+	if err := getTopRepositories(cfg.GitHub, output); err != nil {
+		return err
+	}
+
+	fmt.Print("\u001B[37m[INIT]\u001B[0m \u001B[32mDiscovery complete\u001B[0m\n\n")
+
+	return nil
+}
+
+// ReadRepositories returns the repository URLs listed in the given file
+func ReadRepositories(input string) ([]string, error) {
+	f, err := os.Open(input)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	repositories := []string{}
+	scanner := bufio.NewScanner(f)
+
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			repositories = append(repositories, line)
+		}
+	}
+
+	return repositories, scanner.Err()
+}
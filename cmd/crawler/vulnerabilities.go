@@ -0,0 +1,130 @@
+package crawler
+
+import (
+	"kleio/cmd/database"
+	"kleio/pkg/config"
+	"kleio/pkg/docker"
+	"kleio/pkg/osv"
+	"kleio/pkg/vulns"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// RefreshVulnerabilities updates the offline copy of the OSV database. The data dumps given in imports (as
+// `ecosystem=path`) are imported, and the ones of the other configured ecosystems are downloaded. When offline, the
+// dumps of the other ecosystems are not downloaded, but the stored ones that are not indexed yet are indexed
+func RefreshVulnerabilities(cfg *config.Config, imports []string, offline bool) error {
+	imported := map[string]bool{}
+
+	for _, entry := range imports {
+		ecosystem, path, ok := strings.Cut(entry, "=")
+
+		if !ok {
+			return fmt.Errorf("invalid import %q (expected ecosystem=path)", entry)
+		}
+
+		fmt.Printf("\u001B[37m[OSV]\u001B[0m Importing \u001B[34m%s\u001B[0m from %s", ecosystem, path)
+
+		if err := osv.Import(cfg.OSV, ecosystem, path); err != nil {
+			fmt.Println(" \u001B[31m𐄂\u001B[0m")
+
+			return err
+		}
+
+		fmt.Println(" \u001B[32m✓\u001B[0m")
+
+		imported[ecosystem] = true
+	}
+
+	index := osv.Open(cfg.OSV.Dir)
+
+	for _, ecosystem := range cfg.OSV.Ecosystems {
+		if imported[ecosystem] {
+			continue
+		}
+
+		if offline {
+			if index.Has(ecosystem) || !index.Stored(ecosystem) {
+				continue
+			}
+
+			fmt.Printf("\u001B[37m[OSV]\u001B[0m Indexing \u001B[34m%s\u001B[0m", ecosystem)
+
+			if err := osv.BuildIndex(cfg.OSV, ecosystem); err != nil {
+				fmt.Println(" \u001B[31m𐄂\u001B[0m")
+
+				return err
+			}
+
+			fmt.Println(" \u001B[32m✓\u001B[0m")
+
+			continue
+		}
+
+		fmt.Printf("\u001B[37m[OSV]\u001B[0m Downloading \u001B[34m%s\u001B[0m", ecosystem)
+
+		if err := osv.Download(cfg.OSV, ecosystem); err != nil {
+			fmt.Println(" \u001B[31m𐄂\u001B[0m")
+
+			return err
+		}
+
+		fmt.Println(" \u001B[32m✓\u001B[0m")
+	}
+
+	return nil
+}
+
+// EnrichVulnerabilities matches the npm packages, Actions, and container images saved in neo4j against the configured
+// sources of vulnerabilities, and connects them to the vulnerabilities affecting them
+func EnrichVulnerabilities(cfg *config.Config, driver neo4j.DriverWithContext, ctx context.Context) {
+	database.MigrateVulnerabilities(driver, ctx)
+
+	sources := vulns.New(cfg)
+	vulnerables := database.GetVulnerables(driver, ctx)
+	found := 0
+
+	fmt.Printf("\u001B[37m[VULNS]\u001B[0m Matching \u001B[34m%d\u001B[0m versions and commits\n", len(vulnerables))
+
+	for _, vulnerable := range vulnerables {
+		ecosystem, name, version := "", vulnerable.Component, vulnerable.Version
+
+		switch vulnerable.Type {
+		case "package":
+			ecosystem = osv.EcosystemNpm
+		case "action":
+			ecosystem = osv.EcosystemActions
+		case "container":
+			image, err := docker.ParseImage(vulnerable.Component + ":" + vulnerable.Version)
+
+			if err != nil {
+				continue
+			}
+
+			var ok bool
+
+			if ecosystem, name, version, ok = osv.ImagePackage(image); !ok {
+				continue
+			}
+		}
+
+		vulnerabilities, err := sources.Lookup(ecosystem, name, version)
+
+		if err != nil {
+			fmt.Printf("\u001B[37m[VULNS]\u001B[0m %s \u001B[31m𐄂\u001B[0m \u001B[34m(%v)\u001B[0m\n", vulnerable.FullName, err)
+		}
+
+		for _, vulnerability := range vulnerabilities {
+			if !vulnerability.IsWithdrawn() {
+				found++
+			}
+		}
+
+		database.AddVulnerabilities(vulnerable.Label, vulnerable.FullName, vulnerabilities, driver, ctx)
+	}
+
+	fmt.Printf("\u001B[37m[VULNS]\u001B[0m Found \u001B[34m%d\u001B[0m vulnerabilities \u001B[32m✓\u001B[0m\n", found)
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// A Binding is the commit a tag of an Action pointed to when it was observed
+type Binding struct {
+	Action   string    `bson:"action"`
+	Tag      string    `bson:"tag"`
+	Sha      string    `bson:"sha"`
+	Observed time.Time `bson:"observed"`
+}
+
+// A Bindings records every observed [Binding] in the `tag_bindings` MongoDB collection, so that tags moved between two
+// crawls can be detected. It also remembers the Actions whose tags were already observed during the crawl. A nil
+// Bindings does not record anything
+type Bindings struct {
+	collection *mongo.Collection
+	mutex      sync.Mutex
+	visited    map[string]bool
+}
+
+// NewBindings returns a [Bindings] backed by the given MongoDB database
+func NewBindings(client mongo.Database) *Bindings {
+	return &Bindings{collection: client.Collection("tag_bindings"), visited: map[string]bool{}}
+}
+
+// Visit marks the tags of an Action as observed during the crawl, and returns whether they were not observed yet
+func (b *Bindings) Visit(action string) bool {
+	if b == nil {
+		return false
+	}
+
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if b.visited[action] {
+		return false
+	}
+
+	b.visited[action] = true
+
+	return true
+}
+
+// Latest returns the most recent [Binding] of a tag, and whether the tag was ever observed
+func (b *Bindings) Latest(action, tag string) (Binding, bool) {
+	var binding Binding
+
+	if b == nil {
+		return binding, false
+	}
+
+	err := b.collection.FindOne(
+		context.Background(),
+		bson.D{
+			{Key: "action", Value: action},
+			{Key: "tag", Value: tag},
+		},
+		options.FindOne().SetSort(bson.D{{Key: "observed", Value: -1}}),
+	).Decode(&binding)
+
+	if err == mongo.ErrNoDocuments {
+		return binding, false
+	} else if err != nil {
+		panic(err)
+	}
+
+	return binding, true
+}
+
+// Observe records that a tag points to the given commit, and returns the previous [Binding] of the tag if it pointed
+// to a different commit (i.e., if the tag was moved since it was last observed)
+func (b *Bindings) Observe(action, tag, sha string, observed time.Time) (Binding, bool) {
+	if b == nil {
+		return Binding{}, false
+	}
+
+	previous, found := b.Latest(action, tag)
+
+	if _, err := b.collection.InsertOne(context.Background(), Binding{
+		Action:   action,
+		Tag:      tag,
+		Sha:      sha,
+		Observed: observed.UTC(),
+	}); err != nil {
+		panic(err)
+	}
+
+	return previous, found && previous.Sha != sha
+}
@@ -0,0 +1,136 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/v2/bson"
+	"go.mongodb.org/mongo-driver/v2/mongo"
+	"go.mongodb.org/mongo-driver/v2/mongo/options"
+)
+
+// The kinds of items tracked by the [Ledger]
+const (
+	KindRepository = "repository"
+	KindAction     = "action"
+	KindPass       = "pass"
+)
+
+// The states an item tracked by the [Ledger] can be in
+const (
+	StateQueued    = "queued"
+	StateCloned    = "cloned"
+	StateExtracted = "extracted"
+	StateResolved  = "resolved"
+	StatePersisted = "persisted"
+	StateFailed    = "failed"
+)
+
+// A Checkpoint is the state of a repository or Action in the crawling process
+type Checkpoint struct {
+	Kind    string    `bson:"kind"`
+	Name    string    `bson:"name"`
+	State   string    `bson:"state"`
+	Reason  string    `bson:"reason"`
+	Updated time.Time `bson:"updated"`
+}
+
+// A Ledger records the [Checkpoint] of each repository and Action in the `checkpoints` MongoDB collection, so that
+// interrupted crawls can be resumed. A nil Ledger does not record anything
+type Ledger struct {
+	collection *mongo.Collection
+}
+
+// NewLedger returns a [Ledger] backed by the given MongoDB database
+func NewLedger(client mongo.Database) *Ledger {
+	return &Ledger{collection: client.Collection("checkpoints")}
+}
+
+// Get returns the [Checkpoint] of an item, and whether the item was found
+func (l *Ledger) Get(kind, name string) (Checkpoint, bool) {
+	var checkpoint Checkpoint
+
+	if l == nil {
+		return checkpoint, false
+	}
+
+	err := l.collection.FindOne(context.Background(), bson.D{
+		{Key: "kind", Value: kind},
+		{Key: "name", Value: name},
+	}).Decode(&checkpoint)
+
+	if err == mongo.ErrNoDocuments {
+		return checkpoint, false
+	} else if err != nil {
+		panic(err)
+	}
+
+	return checkpoint, true
+}
+
+// IsDone returns whether an item was already persisted
+func (l *Ledger) IsDone(kind, name string) bool {
+	checkpoint, found := l.Get(kind, name)
+
+	return found && checkpoint.State == StatePersisted
+}
+
+// IsDoneSince returns whether an item was persisted after the given time
+func (l *Ledger) IsDoneSince(kind, name string, since time.Time) bool {
+	checkpoint, found := l.Get(kind, name)
+
+	return found && checkpoint.State == StatePersisted && checkpoint.Updated.After(since)
+}
+
+// Queue marks an item as queued, unless it is already tracked by the [Ledger]
+func (l *Ledger) Queue(kind, name string) {
+	l.update(kind, name, bson.D{
+		{Key: "$setOnInsert", Value: bson.D{
+			{Key: "state", Value: StateQueued},
+			{Key: "reason", Value: ""},
+			{Key: "updated", Value: time.Now().UTC()},
+		}},
+	})
+}
+
+// Mark sets the state of an item
+func (l *Ledger) Mark(kind, name, state string) {
+	l.update(kind, name, bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "state", Value: state},
+			{Key: "reason", Value: ""},
+			{Key: "updated", Value: time.Now().UTC()},
+		}},
+	})
+}
+
+// Fail marks an item as failed, together with the reason of the failure
+func (l *Ledger) Fail(kind, name string, reason any) {
+	l.update(kind, name, bson.D{
+		{Key: "$set", Value: bson.D{
+			{Key: "state", Value: StateFailed},
+			{Key: "reason", Value: fmt.Sprint(reason)},
+			{Key: "updated", Value: time.Now().UTC()},
+		}},
+	})
+}
+
+// update upserts the [Checkpoint] of an item
+func (l *Ledger) update(kind, name string, update bson.D) {
+	if l == nil {
+		return
+	}
+
+	if _, err := l.collection.UpdateOne(
+		context.Background(),
+		bson.D{
+			{Key: "kind", Value: kind},
+			{Key: "name", Value: name},
+		},
+		update,
+		options.UpdateOne().SetUpsert(true),
+	); err != nil {
+		panic(err)
+	}
+}
@@ -1,9 +1,9 @@
 package database
 
 import (
+	"kleio/pkg/config"
 	"context"
 	"fmt"
-	"os"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 	"go.mongodb.org/mongo-driver/v2/mongo"
@@ -11,12 +11,12 @@ import (
 )
 
 // ConnectToNeo is used to connect to the Neo4j instance
-func ConnectToNeo() (neo4j.DriverWithContext, context.Context, error) {
+func ConnectToNeo(cfg config.Database) (neo4j.DriverWithContext, context.Context, error) {
 	ctx := context.Background()
 
-	dbUri := os.Getenv("NEO_URI")
-	dbUser := os.Getenv("NEO_USER")
-	dbPassword := os.Getenv("NEO_PASS")
+	dbUri := cfg.URI
+	dbUser := cfg.User
+	dbPassword := cfg.Pass
 
 	fmt.Printf("\u001B[37m[INIT]\u001B[0m Connecting to Neo4j (\u001B[34m%s\u001B[0m)", dbUri)
 
@@ -37,10 +37,11 @@ func ConnectToNeo() (neo4j.DriverWithContext, context.Context, error) {
 	return driver, ctx, err
 }
 
-func ConnectionToMongo() (mongo.Database, error) {
-	dbUri := os.Getenv("MONGO_URI")
-	dbUsername := os.Getenv("MONGO_USER")
-	dbPassword := os.Getenv("MONGO_PASS")
+// ConnectionToMongo is used to connect to the MongoDB instance
+func ConnectionToMongo(cfg config.Database) (mongo.Database, error) {
+	dbUri := cfg.URI
+	dbUsername := cfg.User
+	dbPassword := cfg.Pass
 
 	fmt.Printf("\u001B[37m[INIT]\u001B[0m Connecting to MongoDB (\u001B[34m%s\u001B[0m)", dbUri)
 
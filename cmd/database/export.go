@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// ExportedUse represents a USES relationship of a workflow commit
+type ExportedUse struct {
+	Target  string `json:"target"`
+	Version string `json:"version"`
+	Type    string `json:"type"`
+	Times   int64  `json:"times"`
+}
+
+// ExportedCommit represents a workflow commit together with the components it uses
+type ExportedCommit struct {
+	Hash string        `json:"hash"`
+	Date time.Time     `json:"date"`
+	Uses []ExportedUse `json:"uses"`
+}
+
+// ExportedWorkflow represents a workflow together with its history
+type ExportedWorkflow struct {
+	Name    string           `json:"name"`
+	Path    string           `json:"path"`
+	Commits []ExportedCommit `json:"commits"`
+}
+
+// ExportedRepository represents a repository together with its workflows
+type ExportedRepository struct {
+	Name      string             `json:"name"`
+	Url       string             `json:"url"`
+	Workflows []ExportedWorkflow `json:"workflows"`
+}
+
+// ExportRepository returns the workflows, commits, and uses of a repository saved in neo4j
+func ExportRepository(repo string, driver neo4j.DriverWithContext, ctx context.Context) ExportedRepository {
+	exported := ExportedRepository{Name: repo, Workflows: []ExportedWorkflow{}}
+	workflows := map[string]int{}
+
+	for _, record := range ExecuteQueryWithRetNeo(
+		`MATCH (r:Repository {full_name: $repo})-[:CONTAINS]->(w:Workflow)-[:PUSHED]->(c:Commit)
+		OPTIONAL MATCH (c)-[u:USES]->(t)
+		WITH r, w, c, collect({target: t.full_name, version: u.version, type: u.type, times: u.times}) AS uses
+		RETURN r.url, w.name, w.path, c.name, c.date, uses
+		ORDER BY w.name, c.date`,
+		map[string]any{
+			"repo": repo,
+		},
+		driver, ctx,
+	) {
+		url, _ := record.Get("r.url")
+		name, _ := record.Get("w.name")
+		path, _ := record.Get("w.path")
+		hash, _ := record.Get("c.name")
+		date, _ := record.Get("c.date")
+		uses, _ := record.Get("uses")
+
+		exported.Url = url.(string)
+
+		index, ok := workflows[name.(string)]
+
+		if !ok {
+			index = len(exported.Workflows)
+			workflows[name.(string)] = index
+
+			exported.Workflows = append(exported.Workflows, ExportedWorkflow{
+				Name:    name.(string),
+				Path:    path.(string),
+				Commits: []ExportedCommit{},
+			})
+		}
+
+		commit := ExportedCommit{Hash: hash.(string), Uses: []ExportedUse{}}
+
+		if date != nil {
+			commit.Date = date.(neo4j.LocalDateTime).Time()
+		}
+
+		for _, useRaw := range uses.([]any) {
+			use := useRaw.(map[string]any)
+
+			if use["target"] == nil {
+				continue
+			}
+
+			exportedUse := ExportedUse{Target: use["target"].(string)}
+
+			if version, ok := use["version"].(string); ok {
+				exportedUse.Version = version
+			}
+
+			if typz, ok := use["type"].(string); ok {
+				exportedUse.Type = typz
+			}
+
+			if times, ok := use["times"].(int64); ok {
+				exportedUse.Times = times
+			}
+
+			commit.Uses = append(commit.Uses, exportedUse)
+		}
+
+		exported.Workflows[index].Commits = append(exported.Workflows[index].Commits, commit)
+	}
+
+	return exported
+}
@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// GetRepositories returns the full names of the repositories saved in neo4j. If repos is not empty, only the
+// repositories contained in it are returned
+func GetRepositories(repos []string, driver neo4j.DriverWithContext, ctx context.Context) []string {
+	names := []string{}
+
+	for _, record := range ExecuteQueryWithRetNeo(
+		`MATCH (r:Repository)
+		WHERE size($repos) = 0 OR r.full_name IN $repos
+		RETURN r.full_name
+		ORDER BY r.full_name`,
+		map[string]any{
+			"repos": repos,
+		},
+		driver, ctx,
+	) {
+		name, _ := record.Get("r.full_name")
+		names = append(names, name.(string))
+	}
+
+	return names
+}
+
+// GetWorkflows returns the full names of the workflows contained in a repository
+func GetWorkflows(repo string, driver neo4j.DriverWithContext, ctx context.Context) []string {
+	workflows := []string{}
+
+	for _, record := range ExecuteQueryWithRetNeo(
+		`MATCH (:Repository {full_name: $repo})-[:CONTAINS]->(w:Workflow)
+		RETURN w.full_name
+		ORDER BY w.full_name`,
+		map[string]any{
+			"repo": repo,
+		},
+		driver, ctx,
+	) {
+		workflow, _ := record.Get("w.full_name")
+		workflows = append(workflows, workflow.(string))
+	}
+
+	return workflows
+}
+
+// GetWorkflowContents returns the decoded contents of all the workflow commits of a repository
+func GetWorkflowContents(repo string, driver neo4j.DriverWithContext, ctx context.Context) []string {
+	contents := []string{}
+
+	for _, record := range ExecuteQueryWithRetNeo(
+		`MATCH (:Repository {full_name: $repo})-[:CONTAINS]->(:Workflow)-[:PUSHED]->(c:Commit)
+		RETURN c.content`,
+		map[string]any{
+			"repo": repo,
+		},
+		driver, ctx,
+	) {
+		contentRaw, _ := record.Get("c.content")
+
+		if contentRaw == nil {
+			continue
+		}
+
+		content, err := base64.StdEncoding.DecodeString(contentRaw.(string))
+
+		if err != nil {
+			continue
+		}
+
+		contents = append(contents, string(content))
+	}
+
+	return contents
+}
+
+// GetSavedCommits returns the hashes of the commits saved for each workflow of a repository, keyed by the name of the
+// workflow
+func GetSavedCommits(repo string, driver neo4j.DriverWithContext, ctx context.Context) map[string]map[string]bool {
+	saved := map[string]map[string]bool{}
+
+	for _, record := range ExecuteQueryWithRetNeo(
+		`MATCH (:Repository {full_name: $repo})-[:CONTAINS]->(w:Workflow)-[:PUSHED]->(c:Commit)
+		RETURN w.name, collect(c.name) AS hashes`,
+		map[string]any{
+			"repo": repo,
+		},
+		driver, ctx,
+	) {
+		name, _ := record.Get("w.name")
+		hashes, _ := record.Get("hashes")
+
+		saved[name.(string)] = map[string]bool{}
+
+		for _, hash := range hashes.([]any) {
+			saved[name.(string)][hash.(string)] = true
+		}
+	}
+
+	return saved
+}
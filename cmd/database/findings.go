@@ -0,0 +1,144 @@
+package database
+
+import (
+	"kleio/pkg/checks"
+	"kleio/pkg/git/model"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// trackFindings runs the built-in checks on the commits of a workflow from the oldest to the latest, and sends the
+// findings to neo4j. Each window in which a finding is present is a separate Finding node, introduced by the first
+// commit it is found in, and removed by the first following commit it is not found in anymore (or by the deletion of
+// the workflow), so that a finding removed and introduced again keeps its earlier windows. The windows still open from
+// a previous crawl are carried over to the commits more recent than their introduction
+func trackFindings(workflow model.File, workflowFull string, driver neo4j.DriverWithContext, ctx context.Context) {
+	history := workflow.GetHistory()
+
+	if len(history) == 0 {
+		return
+	}
+
+	oldest := history[len(history)-1].GetDate()
+
+	for _, commit := range history {
+		if commit.GetDate().Before(oldest) {
+			oldest = commit.GetDate()
+		}
+	}
+
+	// The full name of the open window of each finding, by key
+	open := openFindings(workflowFull, oldest, driver, ctx)
+
+	for i := len(history) - 1; i >= 0; i-- {
+		commit := history[i]
+		commitFull := fmt.Sprintf("%s/%s", workflowFull, commit.GetHash())
+		present := map[string]bool{}
+
+		for _, finding := range checks.Run(&commit) {
+			key := finding.Key()
+			present[key] = true
+
+			// A finding not present in the previous commit opens a new window
+			introduced := false
+
+			if _, ok := open[key]; !ok {
+				open[key] = fmt.Sprintf("%s/%s@%s", workflowFull, key, commit.GetHash())
+				introduced = true
+			}
+
+			ExecuteQueryNeo(
+				`MATCH (w:Workflow {full_name: $workflow})
+				MATCH (c:Commit {full_name: $commit})
+				MERGE (f:Finding {full_name: $full_f})
+				ON CREATE SET f.key = $key, f.check = $check, f.job = $job, f.input = $input,
+					f.introduced_in = $hash, f.introduced_at = $date
+				SET f.message = $message
+				FOREACH (_ IN CASE WHEN $introduced THEN [1] ELSE [] END |
+					SET f.removed_in = null, f.removed_at = null
+				)
+				MERGE (w)-[:FLAGGED]->(f)
+				MERGE (c)-[r:EXHIBITS]->(f)
+				SET r.steps = CASE WHEN $step IN coalesce(r.steps, []) THEN r.steps ELSE coalesce(r.steps, []) + $step END`,
+				map[string]any{
+					"workflow":   workflowFull,
+					"commit":     commitFull,
+					"full_f":     open[key],
+					"key":        key,
+					"check":      finding.Check,
+					"job":        finding.Job,
+					"input":      finding.Input,
+					"step":       finding.Step,
+					"message":    finding.Message,
+					"hash":       commit.GetHash(),
+					"date":       neo4j.LocalDateTimeOf(commit.GetDate()),
+					"introduced": introduced,
+				},
+				driver, ctx,
+			)
+		}
+
+		closed := []string{}
+
+		for key, full := range open {
+			if !present[key] {
+				closed = append(closed, full)
+				delete(open, key)
+			}
+		}
+
+		closeFindings(closed, commit.GetHash(), commit.GetDate(), driver, ctx)
+	}
+
+	if hash, date := workflow.GetDeletion(); workflow.IsDeleted() {
+		closeFindings(slices.Collect(maps.Values(open)), hash, date, driver, ctx)
+	}
+}
+
+// openFindings returns the full names of the open windows of the findings of a workflow introduced before the given
+// date, by key
+func openFindings(workflowFull string, before time.Time, driver neo4j.DriverWithContext, ctx context.Context) map[string]string {
+	open := map[string]string{}
+
+	for _, record := range ExecuteQueryWithRetNeo(
+		`MATCH (:Workflow {full_name: $workflow})-[:FLAGGED]->(f:Finding)
+		WHERE f.removed_in IS NULL AND f.key IS NOT NULL AND f.introduced_at < $before
+		RETURN f.key, f.full_name`,
+		map[string]any{
+			"workflow": workflowFull,
+			"before":   neo4j.LocalDateTimeOf(before),
+		},
+		driver, ctx,
+	) {
+		key, _ := record.Get("f.key")
+		full, _ := record.Get("f.full_name")
+
+		open[key.(string)] = full.(string)
+	}
+
+	return open
+}
+
+// closeFindings marks the given windows of findings as removed by the given commit
+func closeFindings(closed []string, hash string, date time.Time, driver neo4j.DriverWithContext, ctx context.Context) {
+	if len(closed) == 0 {
+		return
+	}
+
+	ExecuteQueryNeo(
+		`MATCH (f:Finding)
+		WHERE f.full_name IN $closed
+		SET f.removed_in = $hash, f.removed_at = $date`,
+		map[string]any{
+			"closed": closed,
+			"hash":   hash,
+			"date":   neo4j.LocalDateTimeOf(date),
+		},
+		driver, ctx,
+	)
+}
@@ -0,0 +1,127 @@
+package database
+
+import (
+	"kleio/pkg/git/model"
+	"context"
+	"strings"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// A PermissionChange is a change of the level of access of the GITHUB_TOKEN to a scope between two consecutive commits
+// of a workflow
+type PermissionChange struct {
+	Repository string
+	Workflow   string
+	Commit     string
+	Date       time.Time
+	Scope      string
+	Old        string
+	New        string
+	Widened    bool
+}
+
+// GetPermissionChanges returns, for the given repositories (or all of them if repos is empty), when the privileges of
+// the GITHUB_TOKEN were widened or narrowed throughout the histories of their workflows. The privileges of a commit are
+// the highest levels of access granted to any of its jobs (or the top-level permissions if it has no jobs)
+func GetPermissionChanges(repos []string, driver neo4j.DriverWithContext, ctx context.Context) []PermissionChange {
+	changes := []PermissionChange{}
+	previous := map[string]map[string]string{}
+
+	for _, record := range ExecuteQueryWithRetNeo(
+		`MATCH (r:Repository)-[:CONTAINS]->(w:Workflow)-[:PUSHED]->(c:Commit)
+		WHERE size($repos) = 0 OR r.full_name IN $repos
+		OPTIONAL MATCH (c)-[:DEFINES]->(j:Job)
+		WITH r, w, c, collect(j.permissions) AS jobs
+		RETURN r.full_name AS repository, w.full_name AS workflow, c.name AS commit, c.date AS date,
+			c.permissions AS permissions, jobs
+		ORDER BY repository, workflow, date`,
+		map[string]any{
+			"repos": repos,
+		},
+		driver, ctx,
+	) {
+		repository, _ := record.Get("repository")
+		workflow, _ := record.Get("workflow")
+		commit, _ := record.Get("commit")
+		dateRaw, _ := record.Get("date")
+		permissions, _ := record.Get("permissions")
+		jobs, _ := record.Get("jobs")
+
+		// Commits saved before permissions were extracted are skipped
+		if permissions == nil {
+			continue
+		}
+
+		levels := map[string]string{}
+
+		if len(jobs.([]any)) == 0 {
+			levels = scopeLevels(permissions.([]any))
+		}
+
+		for _, job := range jobs.([]any) {
+			if job == nil {
+				continue
+			}
+
+			for scope, level := range scopeLevels(job.([]any)) {
+				if model.PermissionRank(level) >= model.PermissionRank(levels[scope]) {
+					levels[scope] = level
+				}
+			}
+		}
+
+		date := time.Time{}
+
+		if dateRaw != nil {
+			date = dateRaw.(neo4j.LocalDateTime).Time()
+		}
+
+		if old, ok := previous[workflow.(string)]; ok {
+			for _, scope := range model.PermissionScopes {
+				oldLevel, newLevel := old[scope], levels[scope]
+
+				if oldLevel == "" {
+					oldLevel = model.PermissionNone
+				}
+
+				if newLevel == "" {
+					newLevel = model.PermissionNone
+				}
+
+				if oldLevel == newLevel {
+					continue
+				}
+
+				changes = append(changes, PermissionChange{
+					Repository: repository.(string),
+					Workflow:   workflow.(string),
+					Commit:     commit.(string),
+					Date:       date,
+					Scope:      scope,
+					Old:        oldLevel,
+					New:        newLevel,
+					Widened:    model.PermissionRank(newLevel) > model.PermissionRank(oldLevel),
+				})
+			}
+		}
+
+		previous[workflow.(string)] = levels
+	}
+
+	return changes
+}
+
+// scopeLevels parses a list of `scope=level` strings
+func scopeLevels(pairs []any) map[string]string {
+	levels := map[string]string{}
+
+	for _, pair := range pairs {
+		if scope, level, ok := strings.Cut(pair.(string), "="); ok {
+			levels[scope] = level
+		}
+	}
+
+	return levels
+}
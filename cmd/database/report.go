@@ -0,0 +1,78 @@
+package database
+
+import (
+	"context"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// Report contains the summary statistics of the data saved in neo4j
+type Report struct {
+	Repositories int64
+	Workflows    int64
+	Commits      int64
+	Uses         map[string]int64
+	Components   map[string]int64
+}
+
+// GetReport computes the summary statistics of the given repositories (or of all of them if repos is empty)
+func GetReport(repos []string, driver neo4j.DriverWithContext, ctx context.Context) Report {
+	report := Report{Uses: map[string]int64{}, Components: map[string]int64{}}
+
+	for _, record := range ExecuteQueryWithRetNeo(
+		`MATCH (r:Repository)
+		WHERE size($repos) = 0 OR r.full_name IN $repos
+		OPTIONAL MATCH (r)-[:CONTAINS]->(w:Workflow)
+		OPTIONAL MATCH (w)-[:PUSHED]->(c:Commit)
+		RETURN count(DISTINCT r) AS repositories, count(DISTINCT w) AS workflows, count(DISTINCT c) AS commits`,
+		map[string]any{
+			"repos": repos,
+		},
+		driver, ctx,
+	) {
+		repositories, _ := record.Get("repositories")
+		workflows, _ := record.Get("workflows")
+		commits, _ := record.Get("commits")
+
+		report.Repositories = repositories.(int64)
+		report.Workflows = workflows.(int64)
+		report.Commits = commits.(int64)
+	}
+
+	for _, record := range ExecuteQueryWithRetNeo(
+		`MATCH (r:Repository)-[:CONTAINS]->(:Workflow)-[:PUSHED]->(:Commit)-[u:USES]->(t)
+		WHERE size($repos) = 0 OR r.full_name IN $repos
+		RETURN u.type AS type, count(u) AS uses`,
+		map[string]any{
+			"repos": repos,
+		},
+		driver, ctx,
+	) {
+		typz, _ := record.Get("type")
+		uses, _ := record.Get("uses")
+
+		if typz == nil {
+			typz = "unknown"
+		}
+
+		report.Uses[typz.(string)] = uses.(int64)
+	}
+
+	for _, record := range ExecuteQueryWithRetNeo(
+		`MATCH (c:Component)
+		RETURN c.type AS type, count(c) AS components`,
+		map[string]any{},
+		driver, ctx,
+	) {
+		typz, _ := record.Get("type")
+		components, _ := record.Get("components")
+
+		if typz == nil {
+			typz = "unknown"
+		}
+
+		report.Components[typz.(string)] = components.(int64)
+	}
+
+	return report
+}
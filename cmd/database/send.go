@@ -2,21 +2,28 @@ package database
 
 import (
 	"kleio/cmd/helpers"
+	"kleio/pkg/diff"
+	"kleio/pkg/docker"
 	"kleio/pkg/git/model"
 	"context"
 	"encoding/base64"
 	"fmt"
-	"os/exec"
+	"math"
+	"regexp"
+	"slices"
 	"strings"
 	"time"
+	"unicode/utf8"
 
 	"github.com/gosuri/uilive"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"go.mongodb.org/mongo-driver/v2/bson"
 	"go.mongodb.org/mongo-driver/v2/mongo"
 )
 
-// addVersion sends the version nodes and relationships to neo4j
-func addVersion(version model.Version, component string, commit string, date time.Time, driver neo4j.DriverWithContext, ctx context.Context) {
+// addVersion sends the version nodes and relationships to neo4j. The jobs and steps (given by full name) using the
+// version are connected to it as well
+func addVersion(version model.Version, component string, commit string, date time.Time, users []string, driver neo4j.DriverWithContext, ctx context.Context) {
 	versionName := version.GetVersionString()
 	name := versionName
 
@@ -41,13 +48,18 @@ func addVersion(version model.Version, component string, commit string, date tim
 				ExecuteQueryNeo(
 					`MATCH (c:Commit {full_name: $commit})
 					MATCH (v:Commit {full_name: $version})
-					MERGE (c)-[:USES {times: $times, version: $semver, type: $type}]->(v)`,
+					MERGE (c)-[:USES {times: $times, version: $semver, type: $type}]->(v)
+					WITH v
+					UNWIND $users AS user
+					MATCH (u:Step|Job {full_name: user})
+					MERGE (u)-[:USES {version: $semver, type: $type}]->(v)`,
 					map[string]any{
 						"commit":  commit,
 						"version": workflowName,
 						"times":   version.GetUses(),
 						"semver":  version.GetVersionString(),
 						"type":    version.GetVersionType(),
+						"users":   users,
 					},
 					driver, ctx,
 				)
@@ -55,32 +67,48 @@ func addVersion(version model.Version, component string, commit string, date tim
 				return
 			}
 		}
-	} else if _, ok := strings.CutPrefix(component, "docker://"); ok {
-		// Connect to docker image
-		componentSplit := strings.Split(strings.TrimPrefix(component, "docker://"), "/")
-		providerName := componentSplit[0]
-		componentName := strings.Join(strings.Split(strings.Join(componentSplit[1:], "/"), ":")[:1], "")
+	} else if reference, ok := strings.CutPrefix(component, "docker://"); ok {
+		// Connect to docker image. Images are identified by their registry and repository, and their versions by
+		// digest if pinned (and by tag otherwise)
+		image, err := docker.ParseImage(reference)
+
+		// References built from expressions (e.g., `docker://${{ matrix.image }}`) cannot be resolved
+		if err != nil {
+			return
+		}
+
+		repositorySplit := strings.Split(image.Repository, "/")
 
 		ExecuteQueryNeo(
 			`MATCH (co:Commit {full_name: $commit})
 			MERGE (v:Vendor {name: $vendor})
-			MERGE (c:Component {full_name: $component, name: $name, type: $type, provider: $provider})
+			MERGE (c:Component {full_name: $component})
+			ON CREATE SET c.name = $name, c.type = $type, c.provider = $provider
 			MERGE (ve:Version {full_name: $version, name: $semver})
+			SET ve.tag = $tag, ve.digest = $digest, ve.pinned = $pinned
 			MERGE (v)-[:PUBLISHES]->(c)
 			MERGE (c)-[:DEPLOYS]->(ve)
-			MERGE (co)-[:USES {times: $times, version: $usemver, type: $utype}]->(ve)`,
+			MERGE (co)-[:USES {times: $times, version: $usemver, type: $utype}]->(ve)
+			WITH ve
+			UNWIND $users AS user
+			MATCH (u:Step|Job {full_name: user})
+			MERGE (u)-[:USES {version: $usemver, type: $utype}]->(ve)`,
 			map[string]any{
-				"vendor":    strings.Split(componentName, "/")[0],
-				"component": componentName,
-				"name":      strings.Split(componentName, "/")[1],
+				"vendor":    image.Namespace(),
+				"component": image.Name(),
+				"name":      repositorySplit[len(repositorySplit)-1],
 				"type":      "container",
-				"provider":  providerName,
-				"version":   fmt.Sprintf("%s/%s", componentName, name),
-				"semver":    name,
+				"provider":  image.Registry,
+				"version":   fmt.Sprintf("%s/%s", image.Name(), image.Version()),
+				"semver":    image.Version(),
+				"tag":       image.Tag,
+				"digest":    image.Digest,
+				"pinned":    image.IsPinned(),
 				"commit":    commit,
 				"times":     version.GetUses(),
 				"usemver":   version.GetVersionString(),
 				"utype":     version.GetVersionType(),
+				"users":     users,
 			},
 			driver, ctx,
 		)
@@ -98,13 +126,18 @@ func addVersion(version model.Version, component string, commit string, date tim
 			ExecuteQueryNeo(
 				`MATCH (c:Commit {full_name: $commit})
 				MATCH (v:Commit {full_name: $version})
-				MERGE (c)-[:USES {times: $times, version: $semver, type: $type}]->(v)`,
+				MERGE (c)-[:USES {times: $times, version: $semver, type: $type}]->(v)
+				WITH v
+				UNWIND $users AS user
+				MATCH (u:Step|Job {full_name: user})
+				MERGE (u)-[:USES {version: $semver, type: $type}]->(v)`,
 				map[string]any{
 					"commit":  commit,
 					"version": fmt.Sprintf("%s/%s", component, name),
 					"times":   version.GetUses(),
 					"semver":  version.GetVersionString(),
 					"type":    version.GetVersionType(),
+					"users":   users,
 				},
 				driver, ctx,
 			)
@@ -127,7 +160,11 @@ func addVersion(version model.Version, component string, commit string, date tim
 					MERGE (v:Version {full_name: $version, name: $vsemver})
 					MERGE (ve:Commit {full_name: $vcommit, name: $hash})
 					MERGE (c)-[:DEPLOYS]->(v)-[:PUSHES]->(ve)
-					MERGE (co)-[:USES {times: $times, version: $semver, type: $type}]->(ve)`,
+					MERGE (co)-[:USES {times: $times, version: $semver, type: $type}]->(ve)
+					WITH ve
+					UNWIND $users AS user
+					MATCH (u:Step|Job {full_name: user})
+					MERGE (u)-[:USES {version: $semver, type: $type}]->(ve)`,
 					map[string]any{
 						"component": component,
 						"commit":    commit,
@@ -138,6 +175,7 @@ func addVersion(version model.Version, component string, commit string, date tim
 						"times":     version.GetUses(),
 						"semver":    version.GetVersionString(),
 						"type":      version.GetVersionType(),
+						"users":     users,
 					},
 					driver, ctx,
 				)
@@ -160,13 +198,18 @@ func addVersion(version model.Version, component string, commit string, date tim
 					ExecuteQueryNeo(
 						`MATCH (v:Commit {full_name: $version})
 						MATCH (c:Commit {full_name: $commit})
-						MERGE (c)-[:USES {times: $times, version: $semver, type: $type}]->(v)`,
+						MERGE (c)-[:USES {times: $times, version: $semver, type: $type}]->(v)
+						WITH v
+						UNWIND $users AS user
+						MATCH (u:Step|Job {full_name: user})
+						MERGE (u)-[:USES {version: $semver, type: $type}]->(v)`,
 						map[string]any{
 							"version": workflowName,
 							"commit":  commit,
 							"times":   version.GetUses(),
 							"semver":  version.GetVersionString(),
 							"type":    version.GetVersionType(),
+							"users":   users,
 						},
 						driver, ctx,
 					)
@@ -178,8 +221,9 @@ func addVersion(version model.Version, component string, commit string, date tim
 	}
 }
 
-// addComponents sends the component nodes and relationships to neo4j
-func addComponents(component model.Component, commit string, date time.Time, driver neo4j.DriverWithContext, ctx context.Context) {
+// addComponents sends the component nodes and relationships to neo4j. The jobs and steps using each version are looked
+// up in users by their `uses` value
+func addComponents(component model.Component, commit string, date time.Time, users map[string][]string, driver neo4j.DriverWithContext, ctx context.Context) {
 	fullName := component.GetName()
 	componentSplit := strings.Split(component.GetName(), "/")
 
@@ -188,7 +232,179 @@ func addComponents(component model.Component, commit string, date time.Time, dri
 	}
 
 	for _, version := range component.GetHistory() {
-		addVersion(*version, fullName, commit, date, driver, ctx)
+		uses := component.GetName()
+
+		// The names of Docker components already contain their version
+		if component.GetCategory() != "docker" && version.GetVersionString() != "" {
+			uses += "@" + version.GetVersionString()
+		}
+
+		addVersion(*version, fullName, commit, date, append([]string{}, users[uses]...), driver, ctx)
+	}
+}
+
+// AddActionComponents connects a commit of an Action (given by full name) to the Actions and Docker images used by
+// its manifest, as done for the commits of workflows
+func AddActionComponents(commit string, date time.Time, components []*model.Component, driver neo4j.DriverWithContext, ctx context.Context) {
+	for _, component := range components {
+		addComponents(*component, commit, date, nil, driver, ctx)
+	}
+}
+
+// MigrateComponents renames the subtypes of the Action components saved by previous versions of Kleio, which were
+// `js` or `js+lock` for Actions with a package.json, to `javascript` (or to the subtype of their most recent commit,
+// if known). Components saved as `composite` are kept, since that was the subtype of any other Action, until they are
+// resolved again. Running it again has no effect
+func MigrateComponents(driver neo4j.DriverWithContext, ctx context.Context) {
+	records := ExecuteQueryWithRetNeo(
+		`MATCH (c:Component {type: "action"})
+		WHERE c.subtype IN ["js", "js+lock"]
+		OPTIONAL MATCH (c)-[:DEPLOYS]->(:Version)-[:PUSHES]->(commit:Commit)
+		WHERE commit.subtype IS NOT NULL
+		WITH c, commit
+		ORDER BY commit.date DESC
+		WITH c, collect(commit.subtype) AS subtypes
+		SET c.subtype = coalesce(head(subtypes), "javascript")
+		RETURN count(c) AS migrated`,
+		map[string]any{},
+		driver, ctx,
+	)
+
+	if migrated, _ := records[0].Get("migrated"); migrated.(int64) > 0 {
+		fmt.Printf("\u001B[37m[ACTIONS]\u001B[0m Migrated the subtype of \u001B[34m%d\u001B[0m Actions\n", migrated)
+	}
+}
+
+// pairs returns the entries of a map as a sorted list of `key=value` strings, since maps cannot be properties in neo4j
+func pairs(values map[string]string) []string {
+	list := []string{}
+
+	for key, value := range values {
+		list = append(list, key+"="+value)
+	}
+
+	slices.Sort(list)
+
+	return list
+}
+
+// secretPairs returns the distinct names of the secrets received by a step or a job, and the `channel=secret` pairs
+// through which they are received (e.g., `with.token=NPM_TOKEN`)
+func secretPairs(received map[string][]string) ([]string, []string) {
+	secrets, inputs := []string{}, []string{}
+
+	for channel, names := range received {
+		secrets = append(secrets, names...)
+
+		for _, name := range names {
+			inputs = append(inputs, channel+"="+name)
+		}
+	}
+
+	slices.Sort(secrets)
+	slices.Sort(inputs)
+
+	return slices.Compact(secrets), inputs
+}
+
+// addJobs sends the job and step nodes of a commit and their relationships to neo4j, and returns the full names of the
+// jobs and steps using each Action, Docker image, or reusable workflow (keyed by their `uses` value)
+func addJobs(commit model.Commit, commitFull string, driver neo4j.DriverWithContext, ctx context.Context) map[string][]string {
+	users := map[string][]string{}
+
+	for _, job := range commit.GetJobs() {
+		jobFull := fmt.Sprintf("%s/%s", commitFull, job.GetId())
+		permissions := job.GetPermissions()
+		jobSecrets, jobInputs := secretPairs(job.GetSecrets())
+
+		ExecuteQueryNeo(
+			`MATCH (c:Commit {full_name: $commit})
+			MERGE (j:Job {full_name: $full_j})
+			SET j.job_id = $id, j.name = $name, j.runs_on = $runs_on, j.needs = $needs, j.if = $if, j.uses = $uses,
+				j.with = $with, j.env = $env, j.permissions = $perms, j.permissions_declared = $decl,
+				j.secrets = $secrets, j.secret_inputs = $inputs
+			MERGE (c)-[:DEFINES]->(j)`,
+			map[string]any{
+				"commit":  commitFull,
+				"full_j":  jobFull,
+				"id":      job.GetId(),
+				"name":    job.GetName(),
+				"runs_on": job.GetRunsOn(),
+				"needs":   job.GetNeeds(),
+				"if":      job.GetCondition(),
+				"uses":    job.GetUses(),
+				"with":    pairs(job.GetWith()),
+				"env":     pairs(job.GetEnv()),
+				"perms":   pairs(permissions.GetScopes()),
+				"decl":    permissions.IsDeclared(),
+				"secrets": jobSecrets,
+				"inputs":  jobInputs,
+			},
+			driver, ctx,
+		)
+
+		if job.GetUses() != "" {
+			users[job.GetUses()] = append(users[job.GetUses()], jobFull)
+		}
+
+		for _, step := range job.GetSteps() {
+			stepFull := fmt.Sprintf("%s/%d", jobFull, step.GetIndex())
+			secrets, inputs := secretPairs(step.GetSecrets())
+
+			ExecuteQueryNeo(
+				`MATCH (j:Job {full_name: $job})
+				MERGE (s:Step {full_name: $full_s})
+				SET s.index = $index, s.step_id = $id, s.name = $name, s.uses = $uses, s.run = $run, s.if = $if,
+					s.with = $with, s.env = $env, s.secrets = $secrets, s.secret_inputs = $inputs
+				MERGE (j)-[:RUNS]->(s)`,
+				map[string]any{
+					"job":     jobFull,
+					"full_s":  stepFull,
+					"index":   step.GetIndex(),
+					"id":      step.GetId(),
+					"name":    step.GetName(),
+					"uses":    step.GetUses(),
+					"run":     step.GetRun(),
+					"if":      step.GetCondition(),
+					"with":    pairs(step.GetWith()),
+					"env":     pairs(step.GetEnv()),
+					"secrets": secrets,
+					"inputs":  inputs,
+				},
+				driver, ctx,
+			)
+
+			if step.GetUses() != "" {
+				users[step.GetUses()] = append(users[step.GetUses()], stepFull)
+			}
+		}
+	}
+
+	return users
+}
+
+// addTriggers sends the trigger nodes of a commit and their relationships to neo4j
+func addTriggers(commit model.Commit, commitFull string, driver neo4j.DriverWithContext, ctx context.Context) {
+	for _, trigger := range commit.GetTriggers() {
+		ExecuteQueryNeo(
+			`MATCH (c:Commit {full_name: $commit})
+			MERGE (t:Trigger {full_name: $full_t})
+			SET t.event = $event, t.types = $types, t.branches = $branches, t.paths = $paths,
+				t.schedules = $schedules, t.workflows = $workflows, t.inputs = $inputs
+			MERGE (c)-[:TRIGGERED_BY]->(t)`,
+			map[string]any{
+				"commit":    commitFull,
+				"full_t":    fmt.Sprintf("%s/%s", commitFull, trigger.GetEvent()),
+				"event":     trigger.GetEvent(),
+				"types":     trigger.GetTypes(),
+				"branches":  trigger.GetBranches(),
+				"paths":     trigger.GetPaths(),
+				"schedules": trigger.GetSchedules(),
+				"workflows": trigger.GetWorkflows(),
+				"inputs":    trigger.GetInputs(),
+			},
+			driver, ctx,
+		)
 	}
 }
 
@@ -196,10 +412,16 @@ func addComponents(component model.Component, commit string, date time.Time, dri
 func addCommits(commit model.Commit, workflow string, driver neo4j.DriverWithContext, ctx context.Context) {
 	commitFull := fmt.Sprintf("%s/%s", workflow, commit.GetHash())
 	content, _ := commit.GetContent(false)
+	author := commit.GetAuthor()
+	committer := commit.GetCommitter()
+	permissions := commit.GetPermissions()
 
 	ExecuteQueryNeo(
 		`MATCH (w:Workflow {full_name: $full})
 		MERGE (c:Commit {name: $hash, date: $date, full_name: $full_c, content: $content})
+		SET c.path = $path, c.author_name = $a_name, c.author_email = $a_email, c.author_date = $a_date,
+			c.committer_name = $c_name, c.committer_email = $c_email, c.committer_date = $c_date,
+			c.permissions = $perms, c.permissions_declared = $decl
 		MERGE (w)-[:PUSHED]->(c)`,
 		map[string]any{
 			"full":    workflow,
@@ -207,27 +429,54 @@ func addCommits(commit model.Commit, workflow string, driver neo4j.DriverWithCon
 			"date":    neo4j.LocalDateTimeOf(commit.GetDate()),
 			"full_c":  commitFull,
 			"content": content,
+			"path":    commit.GetPath(),
+			"a_name":  author.GetName(),
+			"a_email": author.GetEmail(),
+			"a_date":  neo4j.LocalDateTimeOf(author.GetDate()),
+			"c_name":  committer.GetName(),
+			"c_email": committer.GetEmail(),
+			"c_date":  neo4j.LocalDateTimeOf(committer.GetDate()),
+			"perms":   pairs(permissions.GetScopes()),
+			"decl":    permissions.IsDeclared(),
 		},
 		driver, ctx)
 
+	addTriggers(commit, commitFull, driver, ctx)
+	users := addJobs(commit, commitFull, driver, ctx)
+
 	for _, component := range commit.GetComponents() {
-		addComponents(*component, commitFull, commit.GetDate(), driver, ctx)
+		addComponents(*component, commitFull, commit.GetDate(), users, driver, ctx)
 	}
 }
 
-// addWorkflows sends the workflow nodes and relationships to neo4j
-func addWorkflows(workflow model.File, repo string, driver neo4j.DriverWithContext, ctx context.Context, client mongo.Database) {
+// addWorkflows sends the workflow nodes and relationships to neo4j. Only the diffs with the commits whose hashes are not
+// in saved are computed
+func addWorkflows(workflow model.File, repo string, saved map[string]bool, driver neo4j.DriverWithContext, ctx context.Context, client mongo.Database) {
 	workflowFull := fmt.Sprintf("%s/%s", repo, workflow.GetFilename())
+	deletedHash, deletedDate := workflow.GetDeletion()
+
+	var deletedAt any
 
+	if workflow.IsDeleted() {
+		deletedAt = neo4j.LocalDateTimeOf(deletedDate)
+	}
+
+	// Workflows are identified by their full name, since their path might change when renamed
 	ExecuteQueryNeo(
 		`MATCH (r:Repository {full_name: $full})
-		MERGE (w:Workflow {name: $workflow, full_name: $full_w, path: $path})
+		MERGE (w:Workflow {full_name: $full_w})
+		SET w.name = $workflow, w.path = $path, w.paths = $paths,
+			w.deleted = $deleted, w.deleted_in = $deleted_in, w.deleted_at = $deleted_at
 		MERGE (r)-[:CONTAINS]->(w)`,
 		map[string]any{
-			"full":     repo,
-			"workflow": workflow.GetFilename(),
-			"full_w":   workflowFull,
-			"path":     workflow.GetFilepath(),
+			"full":       repo,
+			"workflow":   workflow.GetFilename(),
+			"full_w":     workflowFull,
+			"path":       workflow.GetFilepath(),
+			"paths":      workflow.GetPaths(),
+			"deleted":    workflow.IsDeleted(),
+			"deleted_in": deletedHash,
+			"deleted_at": deletedAt,
 		},
 		driver, ctx,
 	)
@@ -236,31 +485,53 @@ func addWorkflows(workflow model.File, repo string, driver neo4j.DriverWithConte
 		addCommits(commit, workflowFull, driver, ctx)
 	}
 
+	trackFindings(workflow, workflowFull, driver, ctx)
+
+	if len(workflow.GetHistory()) > 0 {
+		DiffWorkflow(workflowFull, saved, driver, ctx, client)
+	}
+}
+
+// DiffWorkflow computes the syntactical diff between the consecutive commits of a workflow, unless the hashes of both
+// of them are in saved (a nil saved computes them all). The diffs between commits that are no longer consecutive (e.g.,
+// since the older commits of a merged branch were saved between them) are deleted
+func DiffWorkflow(workflowFull string, saved map[string]bool, driver neo4j.DriverWithContext, ctx context.Context, client mongo.Database) {
 	// Retrieve all the commits of a workflow and compute the syntactical diff between them
 	commits := ExecuteQueryWithRetNeo(
-		`MATCH (:Workflow {full_name: $workflow})-[PUSHES]->(c:Commit)
-		ORDER BY c.date
-		RETURN c.full_name, c.content, c.date`,
+		`MATCH (:Workflow {full_name: $workflow})-[:PUSHED]->(c:Commit)
+		RETURN c.name, c.full_name, c.content, c.date
+		ORDER BY c.date, c.name`,
 		map[string]any{
 			"workflow": workflowFull,
 		},
 		driver, ctx,
 	)
 
+	pairs := [][]string{}
+
 	for index, value := range commits {
 		if index == len(commits)-1 {
 			continue
 		}
 
+		precHash, _ := value.Get("c.name")
+		succHash, _ := commits[index+1].Get("c.name")
+
 		precFile, _ := value.Get("c.full_name")
 		succFile, _ := commits[index+1].Get("c.full_name")
 
+		pairs = append(pairs, []string{precFile.(string), succFile.(string)})
+
 		precDateRaw, _ := value.Get("c.date")
 		succDateRaw, _ := commits[index+1].Get("c.date")
 
 		precDate := precDateRaw.(neo4j.LocalDateTime).Time()
 		succDate := succDateRaw.(neo4j.LocalDateTime).Time()
 
+		if saved != nil && saved[precHash.(string)] && saved[succHash.(string)] {
+			continue
+		}
+
 		precCommit, _ := value.Get("c.content")
 		succCommit, _ := commits[index+1].Get("c.content")
 
@@ -271,19 +542,11 @@ func addWorkflows(workflow model.File, repo string, driver neo4j.DriverWithConte
 			panic(err)
 		}
 
-		cmd := exec.Command(
-			"/bin/bash", "-c",
-			fmt.Sprintf("gawd --json <(echo '%s') <(echo '%s')",
-				strings.ReplaceAll(string(precContent), "'", "'\\''"),
-				strings.ReplaceAll(string(succContent), "'", "'\\''"),
-			),
-		)
-
-		out, err := cmd.CombinedOutput()
+		changes, err := diff.Compare(precContent, succContent)
 
 		if err != nil {
-			fmt.Print(string(out))
-			
+			fmt.Println(err)
+
 			ExecuteQueryNeo(
 				`MATCH (c1:Commit {full_name: $commit1})
 				MATCH (c2:Commit {full_name: $commit2})
@@ -305,7 +568,7 @@ func addWorkflows(workflow model.File, repo string, driver neo4j.DriverWithConte
 			"diffs", "find", client,
 		); present == "" {
 			mongoId := ExecuteQueryMongo(helpers.GroupByPath(
-				out, precFile.(string), succFile.(string)), "diffs", 
+				changes, precFile.(string), succFile.(string)), "diffs",
 				"insert", client,
 			)
 
@@ -323,10 +586,119 @@ func addWorkflows(workflow model.File, repo string, driver neo4j.DriverWithConte
 			)
 		}
 	}
+
+	removeStaleDiffs(workflowFull, pairs, driver, ctx, client)
 }
 
-// SendToDB adds the given repository to neo4j
-func SendToDB(repository model.Repository, driver neo4j.DriverWithContext, ctx context.Context, client mongo.Database) {
+// removeStaleDiffs deletes the diffs between the commits of a workflow that are not in pairs (i.e., that are no longer
+// consecutive), together with their documents in the `diffs` collection
+func removeStaleDiffs(workflowFull string, pairs [][]string, driver neo4j.DriverWithContext, ctx context.Context, client mongo.Database) {
+	ids := bson.A{}
+
+	for _, record := range ExecuteQueryWithRetNeo(
+		`MATCH (:Workflow {full_name: $workflow})-[:PUSHED]->(c1:Commit)-[d:CHANGED_TO]->(c2:Commit)
+		WHERE NOT [c1.full_name, c2.full_name] IN $pairs
+		WITH d, d.diff AS diff
+		DELETE d
+		RETURN diff`,
+		map[string]any{
+			"workflow": workflowFull,
+			"pairs":    pairs,
+		},
+		driver, ctx,
+	) {
+		diff, _ := record.Get("diff")
+
+		if id, err := bson.ObjectIDFromHex(fmt.Sprint(diff)); err == nil {
+			ids = append(ids, id)
+		}
+	}
+
+	if len(ids) == 0 {
+		return
+	}
+
+	if _, err := client.Collection("diffs").DeleteMany(
+		context.Background(), bson.D{{Key: "_id", Value: bson.D{{Key: "$in", Value: ids}}}},
+	); err != nil {
+		panic(err)
+	}
+}
+
+// migrateWorkflows renames the workflows of a repository saved by previous versions of Kleio, which identified them by
+// their latest filename, to their current identity, together with the full names of their commits, jobs, steps,
+// triggers, and findings, and the diffs between their commits. A saved workflow is only renamed if it pushed the commit
+// that created the file, so that the workflow currently holding a previous filename is not renamed again. Workflows are
+// renamed in two steps, since the previous filename of a workflow can be the current one of another
+func migrateWorkflows(repository model.Repository, driver neo4j.DriverWithContext, ctx context.Context, client mongo.Database) {
+	renames := map[string]string{}
+
+	for _, workflow := range repository.GetFiles() {
+		if previous := workflow.GetPreviousFilename(); previous != "" && previous != workflow.GetFilename() {
+			renames[workflow.GetFilename()] = previous
+		}
+	}
+
+	rename := func(from string, to string, name string, created string) {
+		ExecuteQueryNeo(
+			`MATCH (w:Workflow {full_name: $from})
+			WHERE $created = "" OR EXISTS { (w)-[:PUSHED]->(:Commit {name: $created}) }
+			SET w.full_name = $to, w.name = $name
+			WITH w
+			MATCH (w)-[:PUSHED|FLAGGED]->(n)
+			OPTIONAL MATCH (n)-[:DEFINES|TRIGGERED_BY]->(m)
+			OPTIONAL MATCH (m)-[:RUNS]->(s:Step)
+			WITH collect(n) + collect(m) + collect(s) AS nodes
+			UNWIND nodes AS node
+			WITH DISTINCT node
+			WHERE node.full_name STARTS WITH $from + "/"
+			SET node.full_name = $to + substring(node.full_name, size($from))`,
+			map[string]any{
+				"from":    from,
+				"to":      to,
+				"name":    name,
+				"created": created,
+			},
+			driver, ctx,
+		)
+
+		for _, field := range []string{"from_commit", "to_commit"} {
+			_, err := client.Collection("diffs").UpdateMany(
+				context.Background(),
+				bson.D{{Key: field, Value: bson.D{{Key: "$regex", Value: "^" + regexp.QuoteMeta(from+"/")}}}},
+				mongo.Pipeline{bson.D{{Key: "$set", Value: bson.D{{Key: field, Value: bson.D{{Key: "$concat", Value: bson.A{
+					to, bson.D{{Key: "$substrCP", Value: bson.A{"$" + field, utf8.RuneCountInString(from), math.MaxInt32}}},
+				}}}}}}}},
+			)
+
+			if err != nil {
+				panic(err)
+			}
+		}
+	}
+
+	for _, workflow := range repository.GetFiles() {
+		if previous, ok := renames[workflow.GetFilename()]; ok {
+			rename(
+				repository.GetName()+"/"+previous, repository.GetName()+"/"+workflow.GetFilename()+"@migrating",
+				workflow.GetFilename(), workflow.GetCreation(),
+			)
+		}
+	}
+
+	for _, workflow := range repository.GetFiles() {
+		if _, ok := renames[workflow.GetFilename()]; ok {
+			rename(
+				repository.GetName()+"/"+workflow.GetFilename()+"@migrating", repository.GetName()+"/"+workflow.GetFilename(),
+				workflow.GetFilename(), "",
+			)
+		}
+	}
+}
+
+// SendToDB adds the given repository to neo4j. The histories of its workflows are appended to the ones already saved,
+// and only the diffs with the commits whose hashes are not in saved (keyed by workflow name) are computed
+func SendToDB(repository model.Repository, saved map[string]map[string]bool, driver neo4j.DriverWithContext, ctx context.Context, client mongo.Database) {
 	vendor := strings.Split(repository.GetName(), "/")[0]
 	repo := strings.Split(repository.GetName(), "/")[1]
 
@@ -346,11 +718,13 @@ func SendToDB(repository model.Repository, driver neo4j.DriverWithContext, ctx c
 		driver, ctx,
 	)
 
+	migrateWorkflows(repository, driver, ctx, client)
+
 	writer := uilive.New()
 	writer.Start()
 
 	for i, workflow := range repository.GetFiles() {
-		addWorkflows(workflow, repository.GetName(), driver, ctx, client)
+		addWorkflows(workflow, repository.GetName(), saved[workflow.GetFilename()], driver, ctx, client)
 
 		_, _ = fmt.Fprintf(
 			writer,
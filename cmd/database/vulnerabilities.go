@@ -0,0 +1,184 @@
+package database
+
+import (
+	"kleio/pkg/vulns"
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// A Vulnerable is a node whose vulnerabilities can be looked up: a Version of a package or container image, or a
+// Commit of an Action
+type Vulnerable struct {
+	Label     string
+	FullName  string
+	Type      string
+	Component string
+	Version   string
+}
+
+// AddVulnerabilities connects a Commit or Version node (given by label and full name) to the vulnerabilities affecting
+// it, recording on the relationship the ranges and fixed versions of the component it belongs to. Vulnerabilities are
+// identified by their id, and their other properties are updated at each lookup. Withdrawn vulnerabilities only update
+// the nodes already saved and mark their relationship with the node as `withdrawn`, without connecting them to any
+// other node
+func AddVulnerabilities(label string, fullName string, vulnerabilities []vulns.Vulnerability, driver neo4j.DriverWithContext, ctx context.Context) {
+	if label != "Commit" && label != "Version" {
+		panic(fmt.Sprintf("nodes with label %s cannot be vulnerable", label))
+	}
+
+	for _, vulnerability := range vulnerabilities {
+		params := map[string]any{
+			"full_name": fullName,
+			"id":        vulnerability.Id,
+			"aliases":   vulnerability.Aliases,
+			"summary":   vulnerability.Summary,
+			"cve":       vulnerability.CVE(),
+			"cwes":      vulnerability.CWEs,
+			"cvss":      math.Round(vulnerability.CVSS*100) / 100,
+			"severity":  vulnerability.Severity,
+			"published": localTime(vulnerability.Published),
+			"modified":  localTime(vulnerability.Modified),
+			"withdrawn": localTime(vulnerability.Withdrawn),
+			"sources":   vulnerability.Sources,
+			"affected":  vulnerability.Affected,
+			"fixed":     vulnerability.Fixed,
+		}
+
+		properties := `v.aliases = $aliases, v.summary = $summary, v.cve = $cve, v.cwes = $cwes, v.cvss = $cvss,
+			v.severity = $severity, v.published = $published, v.modified = $modified, v.withdrawn = $withdrawn,
+			v.sources = $sources`
+
+		if vulnerability.IsWithdrawn() {
+			ExecuteQueryNeo(
+				fmt.Sprintf(
+					`MATCH (v:Vulnerability {id: $id})
+					SET %s
+					WITH v
+					MATCH (:%s {full_name: $full_name})-[r:VULNERABLE_TO]->(v)
+					SET r.withdrawn = true`,
+					properties, label,
+				),
+				params,
+				driver, ctx,
+			)
+
+			continue
+		}
+
+		ExecuteQueryNeo(
+			fmt.Sprintf(
+				`MATCH (c:%s {full_name: $full_name})
+				MERGE (v:Vulnerability {id: $id})
+				SET %s
+				MERGE (c)-[r:VULNERABLE_TO]->(v)
+				SET r.affected = $affected, r.fixed = $fixed, r.withdrawn = false`,
+				label, properties,
+			),
+			params,
+			driver, ctx,
+		)
+	}
+}
+
+// MigrateVulnerabilities merges the Vulnerability nodes sharing the same id (saved by previous versions of Kleio, one
+// per score), moving their relationships to the node that is kept, and then makes the id of Vulnerability nodes unique,
+// so that concurrent lookups cannot duplicate them again. The timestamps saved as RFC 3339 strings are converted to
+// local datetimes in UTC. Running it again has no effect
+func MigrateVulnerabilities(driver neo4j.DriverWithContext, ctx context.Context) {
+	records := ExecuteQueryWithRetNeo(
+		`MATCH (v:Vulnerability)
+		WHERE v.id IS NOT NULL
+		WITH v.id AS id, collect(v) AS nodes
+		WHERE size(nodes) > 1
+		WITH head(nodes) AS kept, tail(nodes) AS duplicates
+		UNWIND duplicates AS duplicate
+		OPTIONAL MATCH (c)-[:VULNERABLE_TO]->(duplicate)
+		WITH kept, duplicate, collect(c) AS vulnerables
+		FOREACH (c IN vulnerables | MERGE (c)-[:VULNERABLE_TO]->(kept))
+		DETACH DELETE duplicate
+		RETURN count(*) AS merged`,
+		map[string]any{},
+		driver, ctx,
+	)
+
+	if merged, _ := records[0].Get("merged"); merged.(int64) > 0 {
+		fmt.Printf("\u001B[37m[VULNS]\u001B[0m Merged \u001B[34m%d\u001B[0m duplicate vulnerabilities\n", merged)
+	}
+
+	records = ExecuteQueryWithRetNeo(
+		`MATCH (v:Vulnerability)
+		WHERE v.published IS :: STRING OR v.modified IS :: STRING OR v.withdrawn IS :: STRING
+		SET v.published = CASE WHEN v.published IS :: STRING
+				THEN localdatetime({datetime: datetime({datetime: datetime(v.published), timezone: "UTC"})})
+				ELSE v.published END,
+			v.modified = CASE WHEN v.modified IS :: STRING
+				THEN localdatetime({datetime: datetime({datetime: datetime(v.modified), timezone: "UTC"})})
+				ELSE v.modified END,
+			v.withdrawn = CASE WHEN v.withdrawn IS :: STRING
+				THEN localdatetime({datetime: datetime({datetime: datetime(v.withdrawn), timezone: "UTC"})})
+				ELSE v.withdrawn END
+		RETURN count(v) AS converted`,
+		map[string]any{},
+		driver, ctx,
+	)
+
+	if converted, _ := records[0].Get("converted"); converted.(int64) > 0 {
+		fmt.Printf("\u001B[37m[VULNS]\u001B[0m Converted the timestamps of \u001B[34m%d\u001B[0m vulnerabilities\n", converted)
+	}
+
+	ExecuteQueryNeo(
+		`CREATE CONSTRAINT vulnerability_id IF NOT EXISTS
+		FOR (v:Vulnerability) REQUIRE v.id IS UNIQUE`,
+		map[string]any{},
+		driver, ctx,
+	)
+}
+
+// localTime returns a timestamp as a local datetime in UTC, or nil if it is not set
+func localTime(timestamp time.Time) any {
+	if timestamp.IsZero() {
+		return nil
+	}
+
+	return neo4j.LocalDateTimeOf(timestamp.UTC())
+}
+
+// GetVulnerables returns the versions of the npm packages and container images, and the commits of the Actions saved
+// in neo4j, together with the component and version they belong to
+func GetVulnerables(driver neo4j.DriverWithContext, ctx context.Context) []Vulnerable {
+	vulnerables := []Vulnerable{}
+
+	for _, record := range ExecuteQueryWithRetNeo(
+		`MATCH (co:Component)-[:DEPLOYS]->(v:Version)
+		WHERE co.type IN ["package", "container"]
+		RETURN "Version" AS label, v.full_name AS full_name, co.type AS type, co.full_name AS component,
+			coalesce(v.tag, v.name) AS version
+		UNION
+		MATCH (co:Component {type: "action"})-[:DEPLOYS]->(v:Version)-[:PUSHES]->(c:Commit)
+		RETURN "Commit" AS label, c.full_name AS full_name, co.type AS type, co.full_name AS component,
+			v.name AS version`,
+		map[string]any{},
+		driver, ctx,
+	) {
+		values := [5]string{}
+
+		for i, key := range []string{"label", "full_name", "type", "component", "version"} {
+			value, _ := record.Get(key)
+			values[i], _ = value.(string)
+		}
+
+		vulnerables = append(vulnerables, Vulnerable{
+			Label:     values[0],
+			FullName:  values[1],
+			Type:      values[2],
+			Component: values[3],
+			Version:   values[4],
+		})
+	}
+
+	return vulnerables
+}
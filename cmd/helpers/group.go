@@ -1,22 +1,9 @@
 package helpers
 
 import (
-	"encoding/json"
+	"kleio/pkg/diff"
 )
 
-// rawDiff represents the diff as it comes out of GAWD
-type rawDiff struct {
-	Type string `json:"type"`
-	Old  struct {
-		Path  string `json:"path"`
-		Value string `json:"value"`
-	} `json:"old"`
-	New struct {
-		Path  string `json:"path"`
-		Value string `json:"value"`
-	} `json:"new"`
-}
-
 // DiffBody represents the actual changes present in the [FlatDiff] struct
 type DiffBody struct {
 	Type    string `bson:"type"`
@@ -32,40 +19,28 @@ type FlatDiff struct {
 	Diff       map[string]DiffBody `bson:"diff"`
 }
 
-// GroupByPath converts the diff outputted by GAWD, and groups it by path
-func GroupByPath(raw []byte, precFile, succFile string) FlatDiff {
-	var jsonDiff []rawDiff
+// GroupByPath converts the changes between two commits of a workflow, and groups them by path. The new path of the
+// renamed, moved and added nodes is kept as the modified path
+func GroupByPath(changes []diff.Change, precFile, succFile string) FlatDiff {
 	var bsonFlatDiff FlatDiff
 
 	bsonFlatDiff.FromCommit = precFile
 	bsonFlatDiff.ToCommit = succFile
 	bsonFlatDiff.Diff = map[string]DiffBody{}
 
-	err := json.Unmarshal(raw, &jsonDiff)
-
-	if err != nil {
-		panic(err)
-	}
-
-	for _, diff := range jsonDiff {
-		var path string
+	for _, change := range changes {
 		var pathMod string
 
-		switch diff.Type {
-		case "added":
-			path = diff.New.Path
-		case "renamed", "moved":
-			path = diff.Old.Path
-			pathMod = diff.New.Path
-		default:
-			path = diff.Old.Path
+		switch change.Type {
+		case diff.Added, diff.Renamed, diff.Moved:
+			pathMod = change.New.Path
 		}
 
-		bsonFlatDiff.Diff[path] = DiffBody{
-			Type:    diff.Type,
+		bsonFlatDiff.Diff[change.Path] = DiffBody{
+			Type:    change.Type,
 			PathMod: pathMod,
-			Old:     diff.Old.Value,
-			New:     diff.New.Value,
+			Old:     change.Old.Value,
+			New:     change.New.Value,
 		}
 	}
 
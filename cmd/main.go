@@ -1,16 +1,66 @@
 package main
 
 import (
-	"kleio/cmd/crawler"
-	"kleio/pkg/git"
 	"fmt"
+	"os"
 )
 
+// A command is a kleio subcommand, parsing its own flags
+type command struct {
+	name    string
+	summary string
+	run     func(args []string) error
+}
+
+var commands = []command{
+	{"discover", "Retrieve the URLs of the top GitHub repositories", runDiscover},
+	{"crawl", "Crawl the workflows' histories of the repositories and save them", runCrawl},
+	{"resolve-actions", "Resolve the versions and commits of the Actions used by the saved workflows", runResolveActions},
+	{"diff", "Recompute the syntactical diffs between the saved workflow commits", runDiff},
+	{"export", "Export the saved repositories, workflows, and commits as JSON", runExport},
+	{"report", "Print summary statistics of the saved data", runReport},
+	{"vulndb", "Refresh the offline OSV vulnerability database and match the saved data against the sources of vulnerabilities", runVulndb},
+}
+
+// usage prints the list of available subcommands
+func usage() {
+	fmt.Fprintln(os.Stderr, "Usage: kleio <command> [flags]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Commands:")
+
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-16s %s\n", cmd.name, cmd.summary)
+	}
+
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'kleio <command> -h' for the flags of a command")
+}
+
 func main() {
-	neoDriver, neoCtx, mongoClient := crawler.Initialize()
-	crawler.ExtractWorkflows(neoDriver, neoCtx, mongoClient)
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(2)
+	}
+
+	for _, cmd := range commands {
+		if cmd.name != os.Args[1] {
+			continue
+		}
+
+		if err := cmd.run(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "\u001B[31m[ERROR]\u001B[0m %s\n", err)
+			os.Exit(1)
+		}
 
-	git.DeleteRepo("../tmp")
+		return
+	}
 
-	fmt.Println("All Done")
+	switch os.Args[1] {
+	case "-h", "-help", "--help", "help":
+		usage()
+	default:
+		fmt.Fprintf(os.Stderr, "kleio: unknown command %q\n\n", os.Args[1])
+		usage()
+		os.Exit(2)
+	}
 }
@@ -0,0 +1,58 @@
+package checks
+
+import (
+	"kleio/pkg/git/model"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// untrustedRef matches the expressions and refs pointing to the code of a pull request, which is controlled by its
+// (possibly external) author
+var untrustedRef = regexp.MustCompile(
+	`github\.event\.pull_request\.head\.|github\.event\.pull_request\.merge_commit_sha|github\.head_ref|` +
+		`github\.event\.workflow_run\.head_(sha|branch)|github\.event\.workflow_run\.pull_requests|` +
+		`github\.event\.issue\.pull_request|refs/pull/`,
+)
+
+// untrustedCommand matches the shell commands checking out the code of a pull request
+var untrustedCommand = regexp.MustCompile(`gh pr checkout|git (fetch|checkout|pull)[^\n]*(pull/|\$\{\{[^}]*(head|pull_request))`)
+
+// UntrustedCheckout finds the jobs of workflows triggered by `pull_request_target` or `workflow_run` (which run with
+// the secrets and write permissions of the base repository) that check out the code of a pull request. Running or
+// building that code lets its author take over the workflow (a "pwn request")
+func UntrustedCheckout(commit *model.Commit) []Finding {
+	findings := []Finding{}
+
+	if !hasEvent(commit, "pull_request_target", "workflow_run") {
+		return findings
+	}
+
+	for _, job := range commit.GetJobs() {
+		for _, step := range job.GetSteps() {
+			message := ""
+
+			if action, _, _ := strings.Cut(step.GetUses(), "@"); strings.EqualFold(action, "actions/checkout") {
+				if ref := step.GetWith()["ref"]; untrustedRef.MatchString(ref) {
+					message = fmt.Sprintf("checks out the untrusted ref %s", ref)
+				} else if repository := step.GetWith()["repository"]; untrustedRef.MatchString(repository) {
+					message = fmt.Sprintf("checks out the untrusted repository %s", repository)
+				}
+			} else if untrustedCommand.MatchString(step.GetRun()) {
+				message = "checks out the untrusted code of a pull request in a script"
+			}
+
+			if message != "" {
+				findings = append(findings, Finding{
+					Check:   "untrusted-checkout",
+					Job:     job.GetId(),
+					Step:    step.GetIndex(),
+					StepId:  step.GetId(),
+					Message: message,
+				})
+			}
+		}
+	}
+
+	return findings
+}
@@ -0,0 +1,76 @@
+package checks
+
+import (
+	"kleio/pkg/git/model"
+	"slices"
+	"strconv"
+)
+
+// A Finding is a dangerous pattern found by a check in a job of a workflow. Input is the input or expression the
+// pattern was found in, if a step can contain several findings of the same check
+type Finding struct {
+	Check   string
+	Job     string
+	Step    int
+	StepId  string
+	Input   string
+	Message string
+}
+
+// Key returns the identifier of a [Finding] across the history of a workflow. Findings are identified by their check,
+// job, step, and input (if any), where steps are identified by their id (if any) so that they are not considered
+// removed when the steps of the job are reordered, and by their index otherwise
+func (f Finding) Key() string {
+	key := f.Check + "/" + f.Job + "/" + strconv.Itoa(f.Step)
+
+	if f.StepId != "" {
+		key = f.Check + "/" + f.Job + "/" + f.StepId
+	}
+
+	if f.Input != "" {
+		key += "/" + f.Input
+	}
+
+	return key
+}
+
+// A Check returns the findings of a dangerous pattern in a commit of a workflow
+type Check func(commit *model.Commit) []Finding
+
+// checks are the built-in checks, by name
+var checks = map[string]Check{
+	"untrusted-checkout": UntrustedCheckout,
+	"script-injection":   ScriptInjection,
+}
+
+// Run runs all the built-in checks on a commit of a workflow, and returns their findings ordered by key
+func Run(commit *model.Commit) []Finding {
+	findings := []Finding{}
+
+	for _, check := range checks {
+		findings = append(findings, check(commit)...)
+	}
+
+	slices.SortFunc(findings, func(a, b Finding) int {
+		if a.Key() < b.Key() {
+			return -1
+		} else if a.Key() > b.Key() {
+			return 1
+		}
+
+		return a.Step - b.Step
+	})
+
+	return findings
+}
+
+// hasEvent returns whether a commit of a workflow is triggered by any of the given events
+func hasEvent(commit *model.Commit, events ...string) bool {
+	for _, trigger := range commit.GetTriggers() {
+		if slices.Contains(events, trigger.GetEvent()) {
+			return true
+		}
+	}
+
+	return false
+}
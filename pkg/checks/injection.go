@@ -0,0 +1,83 @@
+package checks
+
+import (
+	"kleio/pkg/expr"
+	"kleio/pkg/git/model"
+	"fmt"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// untrustedInput matches the references to the fields of an event that can be set to arbitrary text by anyone opening
+// an issue, a pull request, a discussion, or a comment, or pushing a branch or a commit
+var untrustedInput = regexp.MustCompile(
+	`^github\.(head_ref|event\.(` +
+		`(issue|pull_request|discussion)\.(title|body)|` +
+		`(comment|review|review_comment)\.body|` +
+		`pull_request\.head\.(ref|label|repo\.default_branch)|` +
+		`pages\.[^.]+\.page_name|` +
+		`(commits\.[^.]+|head_commit|workflow_run\.head_commit)\.(message|author\.(email|name))|` +
+		`workflow_run\.head_branch` +
+		`))$`,
+)
+
+// ScriptInjection finds the steps that interpolate untrusted event fields into a script, either directly in a `run`
+// script or in the `script` input of actions/github-script, or through an environment variable of the workflow, job, or
+// step referenced with `${{ env.X }}`. Expressions are expanded before the script runs, so the author of the field can
+// inject arbitrary commands
+func ScriptInjection(commit *model.Commit) []Finding {
+	findings := []Finding{}
+
+	for _, job := range commit.GetJobs() {
+		for _, step := range job.GetSteps() {
+			script := step.GetRun()
+
+			if action, _, _ := strings.Cut(step.GetUses(), "@"); strings.EqualFold(action, "actions/github-script") {
+				script = step.GetWith()["script"]
+			}
+
+			// Environment variables of the step override the ones of its job, which override the ones of the workflow
+			env := map[string]string{}
+			maps.Copy(env, commit.GetEnv())
+			maps.Copy(env, job.GetEnv())
+			maps.Copy(env, step.GetEnv())
+
+			for _, input := range untrustedInputs(script, env) {
+				findings = append(findings, Finding{
+					Check:   "script-injection",
+					Job:     job.GetId(),
+					Step:    step.GetIndex(),
+					StepId:  step.GetId(),
+					Input:   input,
+					Message: fmt.Sprintf("interpolates the untrusted %s in a script", input),
+				})
+			}
+		}
+	}
+
+	return findings
+}
+
+// untrustedInputs returns the untrusted event fields interpolated in a script, either directly or through the given
+// environment variables, once each
+func untrustedInputs(script string, env map[string]string) []string {
+	inputs := []string{}
+
+	for _, reference := range expr.ReferencesIn(script) {
+		if untrustedInput.MatchString(reference) {
+			inputs = append(inputs, reference)
+		} else if name, ok := strings.CutPrefix(reference, "env."); ok {
+			for _, indirect := range expr.ReferencesIn(env[name]) {
+				if untrustedInput.MatchString(indirect) {
+					inputs = append(inputs, fmt.Sprintf("%s (via env.%s)", indirect, name))
+				}
+			}
+		}
+	}
+
+	slices.Sort(inputs)
+
+	return slices.Compact(inputs)
+}
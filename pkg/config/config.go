@@ -0,0 +1,407 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ==============
+// == DATABASE ==
+// ==============
+
+// Database contains the connection details of a database instance
+type Database struct {
+	URI  string `yaml:"uri"`
+	User string `yaml:"user"`
+	Pass string `yaml:"pass"`
+}
+
+// validate checks that the URI of the [Database] struct can be parsed and uses one of the given schemes
+func (d *Database) validate(name string, schemes []string) error {
+	if d.URI == "" {
+		return fmt.Errorf("%s: uri is required", name)
+	}
+
+	uri, err := url.Parse(d.URI)
+
+	if err != nil {
+		return fmt.Errorf("%s: invalid uri: %w", name, err)
+	}
+
+	if !slices.Contains(schemes, uri.Scheme) || uri.Host == "" {
+		return fmt.Errorf("%s: invalid uri %q (expected scheme://host with scheme one of %v)", name, d.URI, schemes)
+	}
+
+	return nil
+}
+
+// ============
+// == GITHUB ==
+// ============
+
+// GitHub contains the credentials and options used when calling the GitHub API
+type GitHub struct {
+	Token     string   `yaml:"token"`
+	Tokens    []string `yaml:"tokens"`
+	VulnToken string   `yaml:"vuln_token"`
+	PageSize  int      `yaml:"size"`
+	Pages     int      `yaml:"pages"`
+	APIURL    string   `yaml:"api_url"`
+	Retries   int      `yaml:"retries"`
+	TagSource string   `yaml:"tag_source"`
+}
+
+// The sources from which the tags of Actions (and their commits) can be retrieved
+const (
+	TagSourceREST    = "rest"
+	TagSourceGraphQL = "graphql"
+	TagSourceLocal   = "local"
+)
+
+// tagSources contains all the valid sources of tags
+var tagSources = []string{TagSourceREST, TagSourceGraphQL, TagSourceLocal}
+
+// GetTokens returns all the distinct tokens used to call the GitHub API, starting with the main token
+func (g *GitHub) GetTokens() []string {
+	tokens := []string{}
+
+	for _, token := range append([]string{g.Token}, g.Tokens...) {
+		if token != "" && !slices.Contains(tokens, token) {
+			tokens = append(tokens, token)
+		}
+	}
+
+	return tokens
+}
+
+// GetVulnToken returns the token used to retrieve vulnerabilities, falling back to the main token if not set
+func (g *GitHub) GetVulnToken() string {
+	if g.VulnToken != "" {
+		return g.VulnToken
+	}
+
+	if tokens := g.GetTokens(); len(tokens) > 0 {
+		return tokens[0]
+	}
+
+	return ""
+}
+
+// ===========
+// == CRAWL ==
+// ===========
+
+// Crawl contains the options of the crawling process
+type Crawl struct {
+	Workers int `yaml:"workers"`
+}
+
+// =========
+// == OSV ==
+// =========
+
+// OSV contains the options of the offline copy of the OSV vulnerability database
+type OSV struct {
+	Dir        string   `yaml:"dir"`
+	URL        string   `yaml:"url"`
+	Ecosystems []string `yaml:"ecosystems"`
+}
+
+// =====================
+// == VULNERABILITIES ==
+// =====================
+
+// Vulnerabilities contains the sources from which the vulnerabilities of the components are retrieved
+type Vulnerabilities struct {
+	Sources       []string `yaml:"sources"`
+	AdvisoriesDir string   `yaml:"advisories_dir"`
+}
+
+// The sources from which vulnerabilities can be retrieved
+const (
+	VulnSourceOSV   = "osv"
+	VulnSourceGHSA  = "ghsa"
+	VulnSourceLocal = "local"
+)
+
+// vulnSources contains all the valid sources of vulnerabilities
+var vulnSources = []string{VulnSourceOSV, VulnSourceGHSA, VulnSourceLocal}
+
+// ============
+// == CONFIG ==
+// ============
+
+// Config is the configuration of kleio
+type Config struct {
+	ReposDir string          `yaml:"repos_dir"`
+	Neo      Database        `yaml:"neo4j"`
+	Mongo    Database        `yaml:"mongodb"`
+	GitHub   GitHub          `yaml:"github"`
+	Crawl    Crawl           `yaml:"crawl"`
+	OSV      OSV             `yaml:"osv"`
+	Vulns    Vulnerabilities `yaml:"vulnerabilities"`
+}
+
+// A Need is a part of the configuration required by a command
+type Need int
+
+const (
+	NeedNeo Need = iota
+	NeedMongo
+	NeedGitHub
+	NeedSearch
+	NeedCrawl
+	NeedOSV
+	NeedVulns
+)
+
+// A Setting is a configuration value that can be overridden through an environment variable
+type Setting struct {
+	Env   string
+	Usage string
+	set   func(c *Config, value string) error
+}
+
+// Settings contains all the configuration values that can be overridden through environment variables
+var Settings = []Setting{
+	{"REPOS_DIR", "directory where local git repositories are stored", func(c *Config, v string) error {
+		c.ReposDir = v
+		return nil
+	}},
+	{"NEO_URI", "URI of the Neo4j instance", func(c *Config, v string) error {
+		c.Neo.URI = v
+		return nil
+	}},
+	{"NEO_USER", "username of the Neo4j instance", func(c *Config, v string) error {
+		c.Neo.User = v
+		return nil
+	}},
+	{"NEO_PASS", "password of the Neo4j instance", func(c *Config, v string) error {
+		c.Neo.Pass = v
+		return nil
+	}},
+	{"MONGO_URI", "URI of the MongoDB instance", func(c *Config, v string) error {
+		c.Mongo.URI = v
+		return nil
+	}},
+	{"MONGO_USER", "username of the MongoDB instance", func(c *Config, v string) error {
+		c.Mongo.User = v
+		return nil
+	}},
+	{"MONGO_PASS", "password of the MongoDB instance", func(c *Config, v string) error {
+		c.Mongo.Pass = v
+		return nil
+	}},
+	{"GITHUB_PAT", "GitHub Personal Access Token", func(c *Config, v string) error {
+		c.GitHub.Token = v
+		return nil
+	}},
+	{"GITHUB_PATS", "comma-separated list of additional GitHub Personal Access Tokens", func(c *Config, v string) error {
+		c.GitHub.Tokens = strings.Split(v, ",")
+		return nil
+	}},
+	{"GITHUB_PAT_VULN", "GitHub Personal Access Token used to retrieve vulnerabilities", func(c *Config, v string) error {
+		c.GitHub.VulnToken = v
+		return nil
+	}},
+	{"SIZE", "size of the pages retrieved when searching the top repositories", func(c *Config, v string) error {
+		return setInt(&c.GitHub.PageSize, v)
+	}},
+	{"PAGES", "number of pages retrieved when searching the top repositories", func(c *Config, v string) error {
+		return setInt(&c.GitHub.Pages, v)
+	}},
+	{"GITHUB_API_URL", "base URL of the GitHub API (e.g., of a GitHub Enterprise instance)", func(c *Config, v string) error {
+		c.GitHub.APIURL = v
+		return nil
+	}},
+	{"GITHUB_RETRIES", "number of times failed GitHub API calls are retried", func(c *Config, v string) error {
+		return setInt(&c.GitHub.Retries, v)
+	}},
+	{"GITHUB_TAG_SOURCE", "source of the tags of Actions (rest, graphql, or local)", func(c *Config, v string) error {
+		c.GitHub.TagSource = v
+		return nil
+	}},
+	{"WORKERS", "number of repositories cloned and extracted concurrently", func(c *Config, v string) error {
+		return setInt(&c.Crawl.Workers, v)
+	}},
+	{"OSV_DIR", "directory where the OSV vulnerability database is stored", func(c *Config, v string) error {
+		c.OSV.Dir = v
+		return nil
+	}},
+	{"OSV_URL", "base URL of the OSV data dumps", func(c *Config, v string) error {
+		c.OSV.URL = v
+		return nil
+	}},
+	{"OSV_ECOSYSTEMS", "comma-separated list of the OSV ecosystems to download", func(c *Config, v string) error {
+		c.OSV.Ecosystems = strings.Split(v, ",")
+		return nil
+	}},
+	{"VULN_SOURCES", "comma-separated list of the sources of vulnerabilities (osv, ghsa, or local)", func(c *Config, v string) error {
+		c.Vulns.Sources = strings.Split(v, ",")
+		return nil
+	}},
+	{"VULN_ADVISORIES_DIR", "directory of the local advisories (in the OSV format)", func(c *Config, v string) error {
+		c.Vulns.AdvisoriesDir = v
+		return nil
+	}},
+}
+
+// setInt parses value and stores it in field
+func setInt(field *int, value string) error {
+	parsed, err := strconv.Atoi(value)
+
+	if err != nil {
+		return err
+	}
+
+	*field = parsed
+
+	return nil
+}
+
+// Default returns the default configuration
+func Default() *Config {
+	return &Config{
+		ReposDir: "./repos",
+		GitHub: GitHub{
+			PageSize:  50,
+			Pages:     10,
+			APIURL:    "https://api.github.com/",
+			Retries:   5,
+			TagSource: TagSourceREST,
+		},
+		Crawl: Crawl{
+			Workers: 4,
+		},
+		OSV: OSV{
+			Dir:        "./vulndb",
+			URL:        "https://osv-vulnerabilities.storage.googleapis.com/",
+			Ecosystems: []string{"npm", "GitHub Actions", "Bitnami"},
+		},
+		Vulns: Vulnerabilities{
+			Sources: []string{VulnSourceOSV, VulnSourceGHSA},
+		},
+	}
+}
+
+// Load returns the default configuration, overridden by the YAML file at path (if not empty) and by the environment
+// variables listed in [Settings]
+func Load(path string) (*Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		content, err := os.ReadFile(path)
+
+		if err != nil {
+			return nil, err
+		}
+
+		if err = yaml.Unmarshal(content, cfg); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	for _, setting := range Settings {
+		if value, ok := os.LookupEnv(setting.Env); ok && value != "" {
+			if err := cfg.Set(setting.Env, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return cfg, nil
+}
+
+// Set overrides the configuration value identified by the given environment variable name
+func (c *Config) Set(env string, value string) error {
+	for _, setting := range Settings {
+		if setting.Env != env {
+			continue
+		}
+
+		if err := setting.set(c, value); err != nil {
+			return fmt.Errorf("%s: %w", env, err)
+		}
+
+		return nil
+	}
+
+	return fmt.Errorf("unknown setting %s", env)
+}
+
+// Validate checks that the parts of the configuration required by needs are present and valid
+func (c *Config) Validate(needs ...Need) error {
+	var errs []error
+
+	for _, need := range needs {
+		switch need {
+		case NeedNeo:
+			errs = append(errs, c.Neo.validate("neo4j", []string{
+				"neo4j", "neo4j+s", "neo4j+ssc", "bolt", "bolt+s", "bolt+ssc",
+			}))
+		case NeedMongo:
+			errs = append(errs, c.Mongo.validate("mongodb", []string{"mongodb", "mongodb+srv"}))
+		case NeedGitHub:
+			if len(c.GitHub.GetTokens()) == 0 {
+				errs = append(errs, errors.New("github: token is required"))
+			}
+
+			if uri, err := url.Parse(c.GitHub.APIURL); err != nil || uri.Host == "" ||
+				(uri.Scheme != "http" && uri.Scheme != "https") {
+				errs = append(errs, fmt.Errorf("github: invalid api_url %q", c.GitHub.APIURL))
+			}
+
+			if c.GitHub.Retries < 0 {
+				errs = append(errs, fmt.Errorf("github: retries must not be negative, got %d", c.GitHub.Retries))
+			}
+
+			if !slices.Contains(tagSources, c.GitHub.TagSource) {
+				errs = append(errs, fmt.Errorf("github: tag_source must be one of %v, got %q", tagSources, c.GitHub.TagSource))
+			}
+		case NeedSearch:
+			if c.GitHub.PageSize <= 0 || c.GitHub.PageSize > 100 {
+				errs = append(errs, fmt.Errorf("github: size must be between 1 and 100, got %d", c.GitHub.PageSize))
+			}
+
+			if c.GitHub.Pages <= 0 {
+				errs = append(errs, fmt.Errorf("github: pages must be positive, got %d", c.GitHub.Pages))
+			}
+		case NeedCrawl:
+			if c.Crawl.Workers <= 0 {
+				errs = append(errs, fmt.Errorf("crawl: workers must be positive, got %d", c.Crawl.Workers))
+			}
+		case NeedOSV:
+			if c.OSV.Dir == "" {
+				errs = append(errs, errors.New("osv: dir is required"))
+			}
+
+			if uri, err := url.Parse(c.OSV.URL); err != nil || uri.Host == "" ||
+				(uri.Scheme != "http" && uri.Scheme != "https") {
+				errs = append(errs, fmt.Errorf("osv: invalid url %q", c.OSV.URL))
+			}
+		case NeedVulns:
+			for _, source := range c.Vulns.Sources {
+				if !slices.Contains(vulnSources, source) {
+					errs = append(errs, fmt.Errorf("vulnerabilities: sources must be among %v, got %q", vulnSources, source))
+				}
+			}
+
+			if slices.Contains(c.Vulns.Sources, VulnSourceLocal) && c.Vulns.AdvisoriesDir == "" {
+				errs = append(errs, errors.New("vulnerabilities: advisories_dir is required by the local source"))
+			}
+
+			if slices.Contains(c.Vulns.Sources, VulnSourceGHSA) && c.GitHub.GetVulnToken() == "" {
+				errs = append(errs, errors.New("vulnerabilities: a GitHub token (GITHUB_PAT or GITHUB_PAT_VULN) is required by the ghsa source"))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
@@ -0,0 +1,493 @@
+package diff
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The types of the changes between two YAML documents
+const (
+	Added   = "added"
+	Removed = "removed"
+	Changed = "changed"
+	Renamed = "renamed"
+	Moved   = "moved"
+)
+
+// similarityThreshold is the minimum similarity of two nodes for them to be considered the same node after being
+// renamed (for mapping values) or moved (for sequence items)
+const similarityThreshold = 0.5
+
+// A Side is the path and value of a node before or after a change. Paths are dot-separated keys, with the indexes of
+// sequence items between brackets (e.g., `jobs.build.steps[0].uses`), and values are rendered in flow style
+type Side struct {
+	Path  string
+	Value string
+}
+
+// A Change is an operation transforming a YAML document into another one. Added nodes only have a new side, while
+// removed nodes only have an old one. The Path of a change identifies it within its diff: it is the old path of the
+// node, or for added nodes the old path of their parent followed by their key or by their new index prefixed by `+`
+// (e.g., `jobs.build.steps[+1]`), so that a removed item and an item added at the same index have different paths
+type Change struct {
+	Type string
+	Path string
+	Old  Side
+	New  Side
+}
+
+// Compare returns the changes transforming the old YAML document into the new one. Mapping keys whose value is kept
+// (or similar enough) are reported as renamed, and sequence items whose relative position changes as moved
+func Compare(old, new []byte) ([]Change, error) {
+	var oldRoot, newRoot yaml.Node
+
+	if err := yaml.Unmarshal(old, &oldRoot); err != nil {
+		return nil, err
+	}
+
+	if err := yaml.Unmarshal(new, &newRoot); err != nil {
+		return nil, err
+	}
+
+	changes := []Change{}
+	compare(resolve(&oldRoot), resolve(&newRoot), "", "", &changes)
+
+	return changes, nil
+}
+
+// compare appends to changes the changes transforming the old node (at oldPath) into the new one (at newPath)
+func compare(old, new *yaml.Node, oldPath, newPath string, changes *[]Change) {
+	switch {
+	case equal(old, new):
+		return
+	case old.Kind == yaml.MappingNode && new.Kind == yaml.MappingNode:
+		compareMappings(old, new, oldPath, newPath, changes)
+	case old.Kind == yaml.SequenceNode && new.Kind == yaml.SequenceNode:
+		compareSequences(old, new, oldPath, newPath, changes)
+	default:
+		*changes = append(*changes, Change{
+			Type: Changed,
+			Path: oldPath,
+			Old:  Side{Path: oldPath, Value: render(old)},
+			New:  Side{Path: newPath, Value: render(new)},
+		})
+	}
+}
+
+// compareMappings appends to changes the changes between the entries of two mappings
+func compareMappings(old, new *yaml.Node, oldPath, newPath string, changes *[]Change) {
+	oldEntries := entries(old)
+	newEntries := entries(new)
+
+	var removed, added []string
+
+	for _, key := range keys(old) {
+		if _, ok := newEntries[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+
+	for _, key := range keys(new) {
+		if _, ok := oldEntries[key]; ok {
+			compare(oldEntries[key], newEntries[key], join(oldPath, key), join(newPath, key), changes)
+		} else {
+			added = append(added, key)
+		}
+	}
+
+	// Removed and added keys with similar values are the same entry, renamed
+	renamed := map[string]string{}
+
+	for _, pair := range match(removed, added, func(o, n string) float64 {
+		return similarity(oldEntries[o], newEntries[n])
+	}) {
+		o, n := removed[pair[0]], added[pair[1]]
+		renamed[o], renamed[n] = n, o
+
+		*changes = append(*changes, Change{
+			Type: Renamed,
+			Path: join(oldPath, o),
+			Old:  Side{Path: join(oldPath, o), Value: render(oldEntries[o])},
+			New:  Side{Path: join(newPath, n), Value: render(newEntries[n])},
+		})
+
+		compare(oldEntries[o], newEntries[n], join(oldPath, o), join(newPath, n), changes)
+	}
+
+	for _, key := range removed {
+		if _, ok := renamed[key]; !ok {
+			*changes = append(*changes, Change{
+				Type: Removed,
+				Path: join(oldPath, key),
+				Old:  Side{Path: join(oldPath, key), Value: render(oldEntries[key])},
+			})
+		}
+	}
+
+	for _, key := range added {
+		if _, ok := renamed[key]; !ok {
+			*changes = append(*changes, Change{
+				Type: Added,
+				Path: join(oldPath, key),
+				New:  Side{Path: join(newPath, key), Value: render(newEntries[key])},
+			})
+		}
+	}
+}
+
+// compareSequences appends to changes the changes between the items of two sequences. Items are matched first by
+// equality and then by similarity, and matched items not in their original relative order are reported as moved
+func compareSequences(old, new *yaml.Node, oldPath, newPath string, changes *[]Change) {
+	oldItems := old.Content
+	newItems := new.Content
+
+	// Index of the new item matched with each old item (-1 if unmatched)
+	matches := make([]int, len(oldItems))
+	matchedNew := make([]bool, len(newItems))
+
+	for i := range matches {
+		matches[i] = -1
+	}
+
+	for i, oldItem := range oldItems {
+		for j, newItem := range newItems {
+			if !matchedNew[j] && equal(resolve(oldItem), resolve(newItem)) {
+				matches[i] = j
+				matchedNew[j] = true
+
+				break
+			}
+		}
+	}
+
+	var unmatchedOld, unmatchedNew []int
+
+	for i, j := range matches {
+		if j == -1 {
+			unmatchedOld = append(unmatchedOld, i)
+		}
+	}
+
+	for j, matched := range matchedNew {
+		if !matched {
+			unmatchedNew = append(unmatchedNew, j)
+		}
+	}
+
+	for _, pair := range match(unmatchedOld, unmatchedNew, func(i, j int) float64 {
+		return similarity(resolve(oldItems[i]), resolve(newItems[j]))
+	}) {
+		matches[unmatchedOld[pair[0]]] = unmatchedNew[pair[1]]
+		matchedNew[unmatchedNew[pair[1]]] = true
+	}
+
+	// The items in the longest increasing subsequence of matched indexes keep their relative order
+	inOrder := longestIncreasing(matches)
+
+	for i, j := range matches {
+		itemOldPath := fmt.Sprintf("%s[%d]", oldPath, i)
+
+		if j == -1 {
+			*changes = append(*changes, Change{
+				Type: Removed,
+				Path: itemOldPath,
+				Old:  Side{Path: itemOldPath, Value: render(resolve(oldItems[i]))},
+			})
+
+			continue
+		}
+
+		itemNewPath := fmt.Sprintf("%s[%d]", newPath, j)
+
+		if !inOrder[i] {
+			*changes = append(*changes, Change{
+				Type: Moved,
+				Path: itemOldPath,
+				Old:  Side{Path: itemOldPath, Value: render(resolve(oldItems[i]))},
+				New:  Side{Path: itemNewPath, Value: render(resolve(newItems[j]))},
+			})
+		}
+
+		compare(resolve(oldItems[i]), resolve(newItems[j]), itemOldPath, itemNewPath, changes)
+	}
+
+	for j, matched := range matchedNew {
+		if !matched {
+			*changes = append(*changes, Change{
+				Type: Added,
+				Path: fmt.Sprintf("%s[+%d]", oldPath, j),
+				New:  Side{Path: fmt.Sprintf("%s[%d]", newPath, j), Value: render(resolve(newItems[j]))},
+			})
+		}
+	}
+}
+
+// match greedily pairs the old and new elements with the highest similarity (at least [similarityThreshold]), and
+// returns the indexes of the paired elements
+func match[T any](old, new []T, similarity func(o, n T) float64) [][2]int {
+	type candidate struct {
+		o, n  int
+		score float64
+	}
+
+	var candidates []candidate
+
+	for o := range old {
+		for n := range new {
+			if score := similarity(old[o], new[n]); score >= similarityThreshold {
+				candidates = append(candidates, candidate{o, n, score})
+			}
+		}
+	}
+
+	slices.SortStableFunc(candidates, func(a, b candidate) int {
+		switch {
+		case a.score > b.score:
+			return -1
+		case a.score < b.score:
+			return 1
+		default:
+			return 0
+		}
+	})
+
+	var pairs [][2]int
+
+	usedOld := map[int]bool{}
+	usedNew := map[int]bool{}
+
+	for _, c := range candidates {
+		if !usedOld[c.o] && !usedNew[c.n] {
+			usedOld[c.o], usedNew[c.n] = true, true
+			pairs = append(pairs, [2]int{c.o, c.n})
+		}
+	}
+
+	slices.SortFunc(pairs, func(a, b [2]int) int { return a[0] - b[0] })
+
+	return pairs
+}
+
+// longestIncreasing returns which of the matched indexes (-1 meaning unmatched) are part of their longest increasing
+// subsequence
+func longestIncreasing(matches []int) []bool {
+	var indexes []int
+
+	for i, j := range matches {
+		if j != -1 {
+			indexes = append(indexes, i)
+		}
+	}
+
+	// lengths[k] is the length of the longest increasing subsequence ending with indexes[k]
+	lengths := make([]int, len(indexes))
+	previous := make([]int, len(indexes))
+	best := -1
+
+	for k := range indexes {
+		lengths[k], previous[k] = 1, -1
+
+		for p := range k {
+			if matches[indexes[p]] < matches[indexes[k]] && lengths[p]+1 > lengths[k] {
+				lengths[k], previous[k] = lengths[p]+1, p
+			}
+		}
+
+		if best == -1 || lengths[k] > lengths[best] {
+			best = k
+		}
+	}
+
+	inOrder := make([]bool, len(matches))
+
+	for k := best; k != -1; k = previous[k] {
+		inOrder[indexes[k]] = true
+	}
+
+	return inOrder
+}
+
+// similarity returns how similar two nodes are, as the share of their leaves (identified by their relative paths) in
+// common. Leaves with the same path but a different value count as half in common. Scalars are only similar if equal,
+// and nodes of different kinds are never similar
+func similarity(a, b *yaml.Node) float64 {
+	if a.Kind != b.Kind {
+		return 0
+	}
+
+	if a.Kind == yaml.ScalarNode {
+		if a.Value == b.Value {
+			return 1
+		}
+
+		return 0
+	}
+
+	aLeaves := leaves(a, "", map[string][]string{})
+	bLeaves := leaves(b, "", map[string][]string{})
+
+	total, common := 0.0, 0.0
+
+	for path, aValues := range aLeaves {
+		bValues := slices.Clone(bLeaves[path])
+		total += float64(len(aValues))
+		kept := 0
+
+		for _, value := range aValues {
+			if index := slices.Index(bValues, value); index != -1 {
+				bValues = slices.Delete(bValues, index, index+1)
+				kept++
+			}
+		}
+
+		changed := min(len(aValues), len(bLeaves[path])) - kept
+		common += float64(kept) + float64(changed)/2
+	}
+
+	for _, bValues := range bLeaves {
+		total += float64(len(bValues))
+	}
+
+	if total == 0 {
+		return 1
+	}
+
+	return 2 * common / total
+}
+
+// leaves collects the values of the scalar leaves of a node, grouped by their relative path
+func leaves(node *yaml.Node, path string, values map[string][]string) map[string][]string {
+	node = resolve(node)
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		for key, value := range entries(node) {
+			leaves(value, join(path, key), values)
+		}
+	case yaml.SequenceNode:
+		for _, item := range node.Content {
+			leaves(item, path+"[]", values)
+		}
+	default:
+		values[path] = append(values[path], node.Value)
+	}
+
+	return values
+}
+
+// equal returns whether two nodes have the same content. The order of mapping keys is not relevant
+func equal(a, b *yaml.Node) bool {
+	a, b = resolve(a), resolve(b)
+
+	if a.Kind != b.Kind {
+		return false
+	}
+
+	switch a.Kind {
+	case yaml.MappingNode:
+		aEntries, bEntries := entries(a), entries(b)
+
+		if len(aEntries) != len(bEntries) {
+			return false
+		}
+
+		for key, value := range aEntries {
+			if other, ok := bEntries[key]; !ok || !equal(value, other) {
+				return false
+			}
+		}
+
+		return true
+	case yaml.SequenceNode:
+		return slices.EqualFunc(a.Content, b.Content, equal)
+	default:
+		return a.Value == b.Value
+	}
+}
+
+// resolve returns the content of document nodes and the target of aliases
+func resolve(node *yaml.Node) *yaml.Node {
+	for {
+		switch {
+		case node.Kind == yaml.DocumentNode && len(node.Content) > 0:
+			node = node.Content[0]
+		case node.Kind == yaml.AliasNode && node.Alias != nil:
+			node = node.Alias
+		default:
+			return node
+		}
+	}
+}
+
+// keys returns the keys of a mapping in order
+func keys(node *yaml.Node) []string {
+	var keys []string
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i].Value)
+	}
+
+	return keys
+}
+
+// entries returns the values of a mapping by key
+func entries(node *yaml.Node) map[string]*yaml.Node {
+	entries := map[string]*yaml.Node{}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		entries[node.Content[i].Value] = resolve(node.Content[i+1])
+	}
+
+	return entries
+}
+
+// join returns the path of a mapping key
+func join(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// render returns the value of a node, rendering mappings and sequences in flow style
+func render(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+
+	if node.Kind == 0 {
+		return ""
+	}
+
+	out, err := yaml.Marshal(flow(node))
+
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// flow returns a copy of a node in flow style, without comments
+func flow(node *yaml.Node) *yaml.Node {
+	node = resolve(node)
+	copied := *node
+
+	if node.Kind != yaml.ScalarNode {
+		copied.Style = yaml.FlowStyle
+	}
+
+	copied.HeadComment, copied.LineComment, copied.FootComment = "", "", ""
+	copied.Anchor = ""
+	copied.Content = make([]*yaml.Node, len(node.Content))
+
+	for i, child := range node.Content {
+		copied.Content[i] = flow(child)
+	}
+
+	return &copied
+}
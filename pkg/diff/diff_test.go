@@ -0,0 +1,214 @@
+package diff
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		new  string
+		want []Change
+	}{
+		{
+			name: "equal documents",
+			old:  "on: push\njobs:\n  build:\n    runs-on: ubuntu-latest\n",
+			new:  "jobs:\n  build:\n    runs-on: ubuntu-latest\non: push\n",
+			want: []Change{},
+		},
+		{
+			name: "changed scalar",
+			old:  "jobs:\n  build:\n    runs-on: ubuntu-20.04\n",
+			new:  "jobs:\n  build:\n    runs-on: ubuntu-latest\n",
+			want: []Change{
+				{
+					Type: Changed,
+					Path: "jobs.build.runs-on",
+					Old:  Side{Path: "jobs.build.runs-on", Value: "ubuntu-20.04"},
+					New:  Side{Path: "jobs.build.runs-on", Value: "ubuntu-latest"},
+				},
+			},
+		},
+		{
+			name: "added and removed keys",
+			old:  "env:\n  A: a\n",
+			new:  "env:\n  B: b\n",
+			want: []Change{
+				{Type: Removed, Path: "env.A", Old: Side{Path: "env.A", Value: "a"}},
+				{Type: Added, Path: "env.B", New: Side{Path: "env.B", Value: "b"}},
+			},
+		},
+		{
+			name: "renamed key",
+			old:  "jobs:\n  build:\n    runs-on: ubuntu-latest\n    steps:\n      - run: make\n",
+			new:  "jobs:\n  test:\n    runs-on: ubuntu-latest\n    steps:\n      - run: make\n",
+			want: []Change{
+				{
+					Type: Renamed,
+					Path: "jobs.build",
+					Old:  Side{Path: "jobs.build", Value: "{runs-on: ubuntu-latest, steps: [{run: make}]}"},
+					New:  Side{Path: "jobs.test", Value: "{runs-on: ubuntu-latest, steps: [{run: make}]}"},
+				},
+			},
+		},
+		{
+			name: "renamed and changed key",
+			old:  "jobs:\n  build:\n    runs-on: ubuntu-latest\n    timeout-minutes: 5\n    steps:\n      - run: make\n",
+			new:  "jobs:\n  test:\n    runs-on: ubuntu-latest\n    timeout-minutes: 10\n    steps:\n      - run: make\n",
+			want: []Change{
+				{
+					Type: Renamed,
+					Path: "jobs.build",
+					Old:  Side{Path: "jobs.build", Value: "{runs-on: ubuntu-latest, timeout-minutes: 5, steps: [{run: make}]}"},
+					New:  Side{Path: "jobs.test", Value: "{runs-on: ubuntu-latest, timeout-minutes: 10, steps: [{run: make}]}"},
+				},
+				{
+					Type: Changed,
+					Path: "jobs.build.timeout-minutes",
+					Old:  Side{Path: "jobs.build.timeout-minutes", Value: "5"},
+					New:  Side{Path: "jobs.test.timeout-minutes", Value: "10"},
+				},
+			},
+		},
+		{
+			name: "dissimilar keys are not renamed",
+			old:  "jobs:\n  build:\n    runs-on: ubuntu-latest\n",
+			new:  "jobs:\n  test:\n    runs-on: windows-latest\n    timeout-minutes: 10\n",
+			want: []Change{
+				{Type: Removed, Path: "jobs.build", Old: Side{Path: "jobs.build", Value: "{runs-on: ubuntu-latest}"}},
+				{Type: Added, Path: "jobs.test", New: Side{Path: "jobs.test", Value: "{runs-on: windows-latest, timeout-minutes: 10}"}},
+			},
+		},
+		{
+			name: "moved item",
+			old:  "steps:\n  - run: a\n  - run: b\n  - run: c\n",
+			new:  "steps:\n  - run: c\n  - run: a\n  - run: b\n",
+			want: []Change{
+				{
+					Type: Moved,
+					Path: "steps[2]",
+					Old:  Side{Path: "steps[2]", Value: "{run: c}"},
+					New:  Side{Path: "steps[0]", Value: "{run: c}"},
+				},
+			},
+		},
+		{
+			name: "moved and changed item",
+			old:  "steps:\n  - uses: actions/checkout@v3\n    with:\n      depth: 1\n  - run: make\n  - run: make test\n",
+			new:  "steps:\n  - run: make\n  - run: make test\n  - uses: actions/checkout@v4\n    with:\n      depth: 1\n",
+			want: []Change{
+				{
+					Type: Moved,
+					Path: "steps[0]",
+					Old:  Side{Path: "steps[0]", Value: "{uses: actions/checkout@v3, with: {depth: 1}}"},
+					New:  Side{Path: "steps[2]", Value: "{uses: actions/checkout@v4, with: {depth: 1}}"},
+				},
+				{
+					Type: Changed,
+					Path: "steps[0].uses",
+					Old:  Side{Path: "steps[0].uses", Value: "actions/checkout@v3"},
+					New:  Side{Path: "steps[2].uses", Value: "actions/checkout@v4"},
+				},
+			},
+		},
+		{
+			name: "reordered list",
+			old:  "branches: [main, dev, release]\n",
+			new:  "branches: [release, dev, main]\n",
+			want: []Change{
+				{
+					Type: Moved,
+					Path: "branches[1]",
+					Old:  Side{Path: "branches[1]", Value: "dev"},
+					New:  Side{Path: "branches[1]", Value: "dev"},
+				},
+				{
+					Type: Moved,
+					Path: "branches[2]",
+					Old:  Side{Path: "branches[2]", Value: "release"},
+					New:  Side{Path: "branches[0]", Value: "release"},
+				},
+			},
+		},
+		{
+			name: "removed and added items at the same index",
+			old:  "branches: [main, dev]\n",
+			new:  "branches: [main, release]\n",
+			want: []Change{
+				{Type: Removed, Path: "branches[1]", Old: Side{Path: "branches[1]", Value: "dev"}},
+				{Type: Added, Path: "branches[+1]", New: Side{Path: "branches[1]", Value: "release"}},
+			},
+		},
+		{
+			name: "added item at the index of a shifted one",
+			old:  "steps:\n  - run: a\n  - run: b\n",
+			new:  "steps:\n  - run: a\n  - uses: actions/cache@v4\n  - run: b\n",
+			want: []Change{
+				{Type: Added, Path: "steps[+1]", New: Side{Path: "steps[1]", Value: "{uses: actions/cache@v4}"}},
+			},
+		},
+		{
+			name: "added key in a shifted item",
+			old:  "steps:\n  - run: a\n  - run: b\n    shell: sh\n",
+			new:  "steps:\n  - run: b\n    shell: sh\n    env:\n      A: a\n",
+			want: []Change{
+				{Type: Removed, Path: "steps[0]", Old: Side{Path: "steps[0]", Value: "{run: a}"}},
+				{Type: Added, Path: "steps[1].env", New: Side{Path: "steps[0].env", Value: "{A: a}"}},
+			},
+		},
+		{
+			name: "changed kind",
+			old:  "on: push\n",
+			new:  "on: [push, pull_request]\n",
+			want: []Change{
+				{
+					Type: Changed,
+					Path: "on",
+					Old:  Side{Path: "on", Value: "push"},
+					New:  Side{Path: "on", Value: "[push, pull_request]"},
+				},
+			},
+		},
+		{
+			name: "empty mapping and sequence are not moved",
+			old:  "items:\n  - {}\n  - a\n",
+			new:  "items:\n  - a\n  - []\n",
+			want: []Change{
+				{Type: Removed, Path: "items[0]", Old: Side{Path: "items[0]", Value: "{}"}},
+				{Type: Added, Path: "items[+1]", New: Side{Path: "items[1]", Value: "[]"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			changes, err := Compare([]byte(test.old), []byte(test.new))
+
+			if err != nil {
+				t.Fatalf("Compare() error = %v", err)
+			}
+
+			if !slices.Equal(changes, test.want) {
+				t.Errorf("Compare() = %+v, want %+v", changes, test.want)
+			}
+
+			paths := map[string]bool{}
+
+			for _, change := range changes {
+				if paths[change.Path] {
+					t.Errorf("Compare() has several changes with path %q", change.Path)
+				}
+
+				paths[change.Path] = true
+			}
+		})
+	}
+}
+
+func TestCompareMalformed(t *testing.T) {
+	if _, err := Compare([]byte("on: push\n"), []byte("on: [push\n")); err == nil {
+		t.Error("Compare() error = nil, want an error for malformed YAML")
+	}
+}
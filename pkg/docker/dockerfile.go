@@ -0,0 +1,105 @@
+package docker
+
+import (
+	"os"
+	"slices"
+	"strings"
+)
+
+// BaseImages returns the base images of the stages of a Dockerfile (i.e., the images of its `FROM` instructions), in
+// order and without duplicates. Stages built from previous stages or from `scratch` have no base image, and the
+// `ARG` instructions preceding the first stage are substituted with their default values. References that cannot be
+// resolved are skipped
+func BaseImages(dockerfile string) []Image {
+	images := []Image{}
+	stages := []string{"scratch"}
+	args := map[string]string{}
+
+	for _, instruction := range instructions(dockerfile) {
+		fields := strings.Fields(instruction)
+
+		switch strings.ToUpper(fields[0]) {
+		case "ARG":
+			// Only the arguments declared before the first stage can be used in `FROM`
+			if len(stages) > 1 {
+				continue
+			}
+
+			for _, arg := range fields[1:] {
+				if name, value, ok := strings.Cut(arg, "="); ok {
+					args[name] = strings.Trim(value, `"'`)
+				}
+			}
+		case "FROM":
+			reference, alias := "", ""
+
+			for i := 1; i < len(fields); i++ {
+				switch {
+				case strings.HasPrefix(fields[i], "--"):
+					continue
+				case strings.EqualFold(fields[i], "as") && i+1 < len(fields):
+					alias = strings.ToLower(fields[i+1])
+					i++
+				case reference == "":
+					reference = os.Expand(fields[i], func(name string) string {
+						name, fallback, hasFallback := strings.Cut(name, ":-")
+
+						if value, ok := args[name]; ok && value != "" {
+							return value
+						} else if hasFallback {
+							return fallback
+						}
+
+						// Keep unknown variables, so that the reference is not resolved
+						return "${" + name + "}"
+					})
+				}
+			}
+
+			if reference != "" && !slices.Contains(stages, strings.ToLower(reference)) {
+				if image, err := ParseImage(reference); err == nil && !slices.Contains(images, image) {
+					images = append(images, image)
+				}
+			}
+
+			stages = append(stages, alias)
+		}
+	}
+
+	return images
+}
+
+// instructions returns the instructions of a Dockerfile, joining the lines continued with a backslash and skipping
+// comments and empty lines
+func instructions(dockerfile string) []string {
+	var result []string
+	var current strings.Builder
+
+	for _, line := range strings.Split(strings.ReplaceAll(dockerfile, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, "#") || (trimmed == "" && current.Len() == 0) {
+			continue
+		}
+
+		if continued, ok := strings.CutSuffix(trimmed, "\\"); ok {
+			current.WriteString(continued + " ")
+
+			continue
+		}
+
+		current.WriteString(trimmed)
+
+		if instruction := strings.TrimSpace(current.String()); instruction != "" {
+			result = append(result, instruction)
+		}
+
+		current.Reset()
+	}
+
+	if instruction := strings.TrimSpace(current.String()); instruction != "" {
+		result = append(result, instruction)
+	}
+
+	return result
+}
@@ -0,0 +1,118 @@
+package docker
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DefaultRegistry is the registry of the images whose reference does not start with a registry host
+const DefaultRegistry = "docker.io"
+
+// ErrUnresolved is returned when an image reference contains variables or expressions that cannot be resolved
+var ErrUnresolved = errors.New("unresolved variable in image reference")
+
+// digestRegex matches the digests of image manifests (e.g., `sha256:<hex>`)
+var digestRegex = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-fA-F0-9]{32,}$`)
+
+// An Image is a reference to a container image, such as `ghcr.io/owner/image:1.0@sha256:<hex>`
+type Image struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseImage parses an image reference. Images without a registry are hosted on Docker Hub, where official images
+// belong to the `library` namespace
+func ParseImage(reference string) (Image, error) {
+	image := Image{Registry: DefaultRegistry}
+	reference = strings.TrimSpace(strings.TrimPrefix(reference, "docker://"))
+
+	if reference == "" {
+		return image, fmt.Errorf("empty image reference")
+	}
+
+	if strings.ContainsAny(reference, "${}") {
+		return image, ErrUnresolved
+	}
+
+	if name, digest, ok := strings.Cut(reference, "@"); ok {
+		if !digestRegex.MatchString(digest) {
+			return image, fmt.Errorf("invalid digest in image reference %q", reference)
+		}
+
+		reference, image.Digest = name, digest
+	}
+
+	// The tag follows the last colon, unless the colon separates the port of the registry
+	if i := strings.LastIndex(reference, ":"); i != -1 && !strings.Contains(reference[i:], "/") {
+		reference, image.Tag = reference[:i], reference[i+1:]
+	}
+
+	segments := strings.Split(reference, "/")
+
+	if len(segments) > 1 && (strings.ContainsAny(segments[0], ".:") || segments[0] == "localhost") {
+		image.Registry, segments = segments[0], segments[1:]
+	}
+
+	if image.Registry == DefaultRegistry && len(segments) == 1 {
+		segments = append([]string{"library"}, segments...)
+	}
+
+	image.Repository = strings.Join(segments, "/")
+
+	if image.Repository == "" || strings.Contains(image.Repository, "//") {
+		return image, fmt.Errorf("invalid image reference %q", reference)
+	}
+
+	if image.Tag == "" && image.Digest == "" {
+		image.Tag = "latest"
+	}
+
+	return image, nil
+}
+
+// Name returns the name of the [Image] struct, including its registry (e.g., `docker.io/library/alpine`)
+func (i Image) Name() string {
+	return i.Registry + "/" + i.Repository
+}
+
+// Namespace returns the user or organization owning the [Image] struct (e.g., `library` for official images)
+func (i Image) Namespace() string {
+	if namespace, _, ok := strings.Cut(i.Repository, "/"); ok {
+		return namespace
+	}
+
+	return i.Repository
+}
+
+// Version returns the digest of the [Image] struct if pinned, or its tag otherwise
+func (i Image) Version() string {
+	if i.Digest != "" {
+		return i.Digest
+	}
+
+	return i.Tag
+}
+
+// IsPinned returns whether the [Image] struct is pinned by digest, and thus immutable
+func (i Image) IsPinned() bool {
+	return i.Digest != ""
+}
+
+// String returns the canonical reference of the [Image] struct
+func (i Image) String() string {
+	reference := i.Name()
+
+	if i.Tag != "" {
+		reference += ":" + i.Tag
+	}
+
+	if i.Digest != "" {
+		reference += "@" + i.Digest
+	}
+
+	return reference
+}
@@ -0,0 +1,373 @@
+package expr
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Node is a node of the syntax tree of an expression
+type Node interface {
+	node()
+}
+
+// A Literal is a string, number, boolean, or null literal
+type Literal struct {
+	Value string
+}
+
+// An Identifier is a context (e.g., `github`) or a variable of a filter
+type Identifier struct {
+	Name string
+}
+
+// A Property is the dereference of a property of an object (e.g., `github.event`)
+type Property struct {
+	Object Node
+	Name   string
+}
+
+// An Index is the dereference of an object or array by index (e.g., `secrets['TOKEN']`). A nil index is a filter
+// (e.g., `github.event.commits[*]` or `github.event.commits.*`)
+type Index struct {
+	Object Node
+	Index  Node
+}
+
+// A Call is the call of a function (e.g., `toJSON(secrets)`)
+type Call struct {
+	Name string
+	Args []Node
+}
+
+// A Unary is a negation (e.g., `!cancelled()`)
+type Unary struct {
+	Operator string
+	Operand  Node
+}
+
+// A Binary is a logical or comparison operation (e.g., `github.event_name == 'push'`)
+type Binary struct {
+	Operator string
+	Left     Node
+	Right    Node
+}
+
+func (Literal) node()    {}
+func (Identifier) node() {}
+func (Property) node()   {}
+func (Index) node()      {}
+func (Call) node()       {}
+func (Unary) node()      {}
+func (Binary) node()     {}
+
+// The kinds of the tokens of an expression
+const (
+	tokenEOF = iota
+	tokenIdentifier
+	tokenString
+	tokenNumber
+	tokenOperator
+)
+
+// A token of an expression
+type token struct {
+	kind  int
+	value string
+}
+
+// tokenize splits an expression in tokens
+func tokenize(expression string) ([]token, error) {
+	var tokens []token
+
+	for i := 0; i < len(expression); {
+		c := expression[i]
+
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '\'':
+			// Quotes in string literals are escaped by doubling them
+			var value strings.Builder
+
+			for i++; ; i++ {
+				if i >= len(expression) {
+					return nil, fmt.Errorf("unterminated string in %q", expression)
+				}
+
+				if expression[i] == '\'' {
+					if i+1 < len(expression) && expression[i+1] == '\'' {
+						value.WriteByte('\'')
+						i++
+
+						continue
+					}
+
+					i++
+
+					break
+				}
+
+				value.WriteByte(expression[i])
+			}
+
+			tokens = append(tokens, token{tokenString, value.String()})
+		case isDigit(c) || (c == '-' && i+1 < len(expression) && isDigit(expression[i+1])):
+			start := i
+
+			for i++; i < len(expression) && (isIdentifier(expression[i]) || expression[i] == '.'); i++ {
+			}
+
+			tokens = append(tokens, token{tokenNumber, expression[start:i]})
+		case isIdentifier(c):
+			start := i
+
+			for ; i < len(expression) && (isIdentifier(expression[i]) || expression[i] == '-'); i++ {
+			}
+
+			tokens = append(tokens, token{tokenIdentifier, expression[start:i]})
+		default:
+			operator := ""
+
+			for _, candidate := range []string{"==", "!=", "<=", ">=", "&&", "||", "(", ")", "[", "]", ".", ",", "!", "<", ">", "*"} {
+				if strings.HasPrefix(expression[i:], candidate) {
+					operator = candidate
+
+					break
+				}
+			}
+
+			if operator == "" {
+				return nil, fmt.Errorf("unexpected character %q in %q", c, expression)
+			}
+
+			tokens = append(tokens, token{tokenOperator, operator})
+			i += len(operator)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF}), nil
+}
+
+// isDigit returns whether a character is a digit
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// isIdentifier returns whether a character can be part of an identifier
+func isIdentifier(c byte) bool {
+	return c == '_' || isDigit(c) || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+// A parser of the tokens of an expression, by recursive descent
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+// precedences are the precedences of the binary operators (the higher, the tighter)
+var precedences = map[string]int{
+	"||": 1,
+	"&&": 2,
+	"==": 3, "!=": 3,
+	"<": 4, "<=": 4, ">": 4, ">=": 4,
+}
+
+// Parse parses an expression (without the enclosing `${{ }}`) into its syntax tree
+func Parse(expression string) (Node, error) {
+	tokens, err := tokenize(expression)
+
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	node, err := p.binary(1)
+
+	if err != nil {
+		return nil, err
+	}
+
+	if p.peek().kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q in %q", p.peek().value, expression)
+	}
+
+	return node, nil
+}
+
+// peek returns the current token
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+// next returns the current token and advances to the following one
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+
+	if t.kind != tokenEOF {
+		p.pos++
+	}
+
+	return t
+}
+
+// expect consumes the given operator, or returns an error
+func (p *parser) expect(operator string) error {
+	if t := p.next(); t.kind != tokenOperator || t.value != operator {
+		return fmt.Errorf("expected %q, found %q", operator, t.value)
+	}
+
+	return nil
+}
+
+// binary parses the binary operations whose operator has at least the given precedence
+func (p *parser) binary(precedence int) (Node, error) {
+	left, err := p.unary()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		current, ok := precedences[t.value]
+
+		if t.kind != tokenOperator || !ok || current < precedence {
+			return left, nil
+		}
+
+		p.next()
+		right, err := p.binary(current + 1)
+
+		if err != nil {
+			return nil, err
+		}
+
+		left = Binary{Operator: t.value, Left: left, Right: right}
+	}
+}
+
+// unary parses negations
+func (p *parser) unary() (Node, error) {
+	if t := p.peek(); t.kind == tokenOperator && t.value == "!" {
+		p.next()
+		operand, err := p.unary()
+
+		if err != nil {
+			return nil, err
+		}
+
+		return Unary{Operator: "!", Operand: operand}, nil
+	}
+
+	return p.postfix()
+}
+
+// postfix parses a primary expression followed by any number of dereferences
+func (p *parser) postfix() (Node, error) {
+	node, err := p.primary()
+
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+
+		if t.kind != tokenOperator {
+			return node, nil
+		}
+
+		switch t.value {
+		case ".":
+			p.next()
+			name := p.next()
+
+			switch {
+			case name.kind == tokenOperator && name.value == "*":
+				node = Index{Object: node}
+			case name.kind == tokenIdentifier || name.kind == tokenNumber:
+				node = Property{Object: node, Name: name.value}
+			default:
+				return nil, fmt.Errorf("unexpected %q after '.'", name.value)
+			}
+		case "[":
+			p.next()
+
+			if star := p.peek(); star.kind == tokenOperator && star.value == "*" {
+				p.next()
+				node = Index{Object: node}
+			} else {
+				index, err := p.binary(1)
+
+				if err != nil {
+					return nil, err
+				}
+
+				node = Index{Object: node, Index: index}
+			}
+
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+		default:
+			return node, nil
+		}
+	}
+}
+
+// primary parses literals, identifiers, function calls, and parenthesised expressions
+func (p *parser) primary() (Node, error) {
+	t := p.next()
+
+	switch t.kind {
+	case tokenString, tokenNumber:
+		return Literal{Value: t.value}, nil
+	case tokenIdentifier:
+		if next := p.peek(); next.kind == tokenOperator && next.value == "(" {
+			p.next()
+
+			var args []Node
+
+			for {
+				if closing := p.peek(); closing.kind == tokenOperator && closing.value == ")" {
+					p.next()
+
+					return Call{Name: t.value, Args: args}, nil
+				}
+
+				if len(args) > 0 {
+					if err := p.expect(","); err != nil {
+						return nil, err
+					}
+				}
+
+				arg, err := p.binary(1)
+
+				if err != nil {
+					return nil, err
+				}
+
+				args = append(args, arg)
+			}
+		}
+
+		switch t.value {
+		case "true", "false", "null":
+			return Literal{Value: t.value}, nil
+		}
+
+		return Identifier{Name: t.value}, nil
+	case tokenOperator:
+		if t.value == "(" {
+			node, err := p.binary(1)
+
+			if err != nil {
+				return nil, err
+			}
+
+			return node, p.expect(")")
+		}
+	}
+
+	return nil, fmt.Errorf("unexpected %q", t.value)
+}
@@ -0,0 +1,159 @@
+package expr
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       Node
+	}{
+		{
+			name:       "context",
+			expression: "github",
+			want:       Identifier{Name: "github"},
+		},
+		{
+			name:       "property",
+			expression: "github.event.issue.title",
+			want: Property{
+				Object: Property{Object: Property{Object: Identifier{Name: "github"}, Name: "event"}, Name: "issue"},
+				Name:   "title",
+			},
+		},
+		{
+			name:       "hyphenated property",
+			expression: "steps.my-step.outputs.result",
+			want: Property{
+				Object: Property{Object: Property{Object: Identifier{Name: "steps"}, Name: "my-step"}, Name: "outputs"},
+				Name:   "result",
+			},
+		},
+		{
+			name:       "string index",
+			expression: "secrets['TOKEN']",
+			want:       Index{Object: Identifier{Name: "secrets"}, Index: Literal{Value: "TOKEN"}},
+		},
+		{
+			name:       "filter",
+			expression: "github.event.commits.*.message",
+			want: Property{
+				Object: Index{Object: Property{Object: Property{Object: Identifier{Name: "github"}, Name: "event"}, Name: "commits"}},
+				Name:   "message",
+			},
+		},
+		{
+			name:       "bracket filter",
+			expression: "needs[*]",
+			want:       Index{Object: Identifier{Name: "needs"}},
+		},
+		{
+			name:       "function call",
+			expression: "contains(github.ref, 'release')",
+			want: Call{Name: "contains", Args: []Node{
+				Property{Object: Identifier{Name: "github"}, Name: "ref"},
+				Literal{Value: "release"},
+			}},
+		},
+		{
+			name:       "function call without arguments",
+			expression: "always()",
+			want:       Call{Name: "always"},
+		},
+		{
+			name:       "dereference of a function result",
+			expression: "fromJSON(steps.meta.outputs.json).tags",
+			want: Property{
+				Object: Call{Name: "fromJSON", Args: []Node{
+					Property{Object: Property{Object: Property{Object: Identifier{Name: "steps"}, Name: "meta"}, Name: "outputs"}, Name: "json"},
+				}},
+				Name: "tags",
+			},
+		},
+		{
+			name:       "escaped quote",
+			expression: "'it''s'",
+			want:       Literal{Value: "it's"},
+		},
+		{
+			name:       "literals",
+			expression: "null || -1.5",
+			want:       Binary{Operator: "||", Left: Literal{Value: "null"}, Right: Literal{Value: "-1.5"}},
+		},
+		{
+			name:       "precedence",
+			expression: "a || b && c == 'x'",
+			want: Binary{
+				Operator: "||",
+				Left:     Identifier{Name: "a"},
+				Right: Binary{
+					Operator: "&&",
+					Left:     Identifier{Name: "b"},
+					Right:    Binary{Operator: "==", Left: Identifier{Name: "c"}, Right: Literal{Value: "x"}},
+				},
+			},
+		},
+		{
+			name:       "left associativity",
+			expression: "a && b && c",
+			want: Binary{
+				Operator: "&&",
+				Left:     Binary{Operator: "&&", Left: Identifier{Name: "a"}, Right: Identifier{Name: "b"}},
+				Right:    Identifier{Name: "c"},
+			},
+		},
+		{
+			name:       "parentheses and negation",
+			expression: "!(a || b) && c >= 2",
+			want: Binary{
+				Operator: "&&",
+				Left:     Unary{Operator: "!", Operand: Binary{Operator: "||", Left: Identifier{Name: "a"}, Right: Identifier{Name: "b"}}},
+				Right:    Binary{Operator: ">=", Left: Identifier{Name: "c"}, Right: Literal{Value: "2"}},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node, err := Parse(test.expression)
+
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", test.expression, err)
+			}
+
+			if !reflect.DeepEqual(node, test.want) {
+				t.Errorf("Parse(%q) = %#v, want %#v", test.expression, node, test.want)
+			}
+		})
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+	}{
+		{name: "empty", expression: ""},
+		{name: "unterminated string", expression: "github.ref == 'main"},
+		{name: "unterminated call", expression: "contains(github.ref, 'x'"},
+		{name: "missing comma", expression: "format('{0}' github.ref)"},
+		{name: "unclosed index", expression: "secrets['TOKEN'"},
+		{name: "unclosed parenthesis", expression: "(a || b"},
+		{name: "dangling operator", expression: "a &&"},
+		{name: "dangling dot", expression: "github."},
+		{name: "trailing token", expression: "a b"},
+		{name: "unexpected character", expression: "github.ref ~ 'x'"},
+		{name: "bare opening", expression: "${{"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if node, err := Parse(test.expression); err == nil {
+				t.Errorf("Parse(%q) = %#v, want an error", test.expression, node)
+			}
+		})
+	}
+}
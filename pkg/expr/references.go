@@ -0,0 +1,117 @@
+package expr
+
+import (
+	"slices"
+	"strings"
+)
+
+// Expressions returns the expressions (without the enclosing `${{ }}`) embedded in a string, such as the value of a
+// `with` input or a `run` script
+func Expressions(value string) []string {
+	var expressions []string
+
+	for {
+		start := strings.Index(value, "${{")
+
+		if start == -1 {
+			return expressions
+		}
+
+		value = value[start+3:]
+		end, quoted := -1, false
+
+		// The closing braces might be contained in a string literal
+		for i := 0; i+1 < len(value); i++ {
+			if value[i] == '\'' {
+				quoted = !quoted
+			} else if !quoted && value[i] == '}' && value[i+1] == '}' {
+				end = i
+
+				break
+			}
+		}
+
+		if end == -1 {
+			return expressions
+		}
+
+		expressions = append(expressions, strings.TrimSpace(value[:end]))
+		value = value[end+2:]
+	}
+}
+
+// References returns the paths of the contexts referenced by an expression (e.g., `secrets.TOKEN` or
+// `github.event.issue.title`), sorted and without duplicates. String indexes are dereferenced as properties, while
+// filters and dynamic indexes are written as `*`
+func References(node Node) []string {
+	var references []string
+
+	collect(node, &references)
+	slices.Sort(references)
+
+	return slices.Compact(references)
+}
+
+// ReferencesIn returns the references of all the expressions embedded in a string. Invalid expressions are skipped
+func ReferencesIn(value string) []string {
+	var references []string
+
+	for _, expression := range Expressions(value) {
+		if node, err := Parse(expression); err == nil {
+			references = append(references, References(node)...)
+		}
+	}
+
+	slices.Sort(references)
+
+	return slices.Compact(references)
+}
+
+// collect appends to references the paths referenced by a node
+func collect(node Node, references *[]string) {
+	switch n := node.(type) {
+	case Identifier, Property, Index:
+		if path, ok := reference(n, references); ok {
+			*references = append(*references, path)
+		}
+	case Call:
+		for _, arg := range n.Args {
+			collect(arg, references)
+		}
+	case Unary:
+		collect(n.Operand, references)
+	case Binary:
+		collect(n.Left, references)
+		collect(n.Right, references)
+	}
+}
+
+// reference returns the path of a chain of dereferences, and whether the chain starts from a context. The dynamic
+// indexes of the chain are collected as well
+func reference(node Node, references *[]string) (string, bool) {
+	switch n := node.(type) {
+	case Identifier:
+		return n.Name, true
+	case Property:
+		path, ok := reference(n.Object, references)
+
+		return path + "." + n.Name, ok
+	case Index:
+		path, ok := reference(n.Object, references)
+
+		if literal, isLiteral := n.Index.(Literal); isLiteral {
+			return path + "." + literal.Value, ok
+		}
+
+		if n.Index != nil {
+			collect(n.Index, references)
+		}
+
+		return path + ".*", ok
+	default:
+		// Dereferences of the result of a function (e.g., `fromJSON(...).key`) are not references to a context
+		collect(node, references)
+
+		return "", false
+	}
+}
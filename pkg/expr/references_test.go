@@ -0,0 +1,124 @@
+package expr
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestExpressions(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{name: "no expression", value: "echo hello", want: nil},
+		{name: "single", value: "echo ${{ github.ref }}", want: []string{"github.ref"}},
+		{
+			name:  "several",
+			value: "echo ${{ github.ref }}\necho ${{github.sha}}",
+			want:  []string{"github.ref", "github.sha"},
+		},
+		{
+			name:  "closing braces in a string",
+			value: "echo ${{ format('{{0}}', github.ref) }}",
+			want:  []string{"format('{{0}}', github.ref)"},
+		},
+		{name: "bare opening", value: "echo ${{", want: nil},
+		{name: "unclosed", value: "echo ${{ github.ref", want: nil},
+		{
+			name:  "unclosed after a valid one",
+			value: "echo ${{ github.ref }} ${{ github.sha",
+			want:  []string{"github.ref"},
+		},
+		{name: "unterminated string", value: "echo ${{ 'main }}", want: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if expressions := Expressions(test.value); !slices.Equal(expressions, test.want) {
+				t.Errorf("Expressions(%q) = %q, want %q", test.value, expressions, test.want)
+			}
+		})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		name       string
+		expression string
+		want       []string
+	}{
+		{name: "literal", expression: "'main'", want: nil},
+		{name: "context", expression: "github.event.issue.title", want: []string{"github.event.issue.title"}},
+		{name: "string index", expression: "secrets['NPM_TOKEN']", want: []string{"secrets.NPM_TOKEN"}},
+		{name: "filter", expression: "github.event.commits[*].message", want: []string{"github.event.commits.*.message"}},
+		{
+			name:       "dynamic index",
+			expression: "secrets[matrix.secret]",
+			want:       []string{"matrix.secret", "secrets.*"},
+		},
+		{
+			name:       "function arguments",
+			expression: "format('{0} {1}', github.head_ref, toJSON(secrets))",
+			want:       []string{"github.head_ref", "secrets"},
+		},
+		{
+			name:       "dereference of a function result",
+			expression: "fromJSON(steps.meta.outputs.json).tags",
+			want:       []string{"steps.meta.outputs.json"},
+		},
+		{
+			name:       "operators",
+			expression: "!cancelled() && (github.event_name == 'push' || env.FORCE)",
+			want:       []string{"env.FORCE", "github.event_name"},
+		},
+		{
+			name:       "duplicates",
+			expression: "github.ref == 'a' || github.ref == 'b'",
+			want:       []string{"github.ref"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			node, err := Parse(test.expression)
+
+			if err != nil {
+				t.Fatalf("Parse(%q) error = %v", test.expression, err)
+			}
+
+			if references := References(node); !slices.Equal(references, test.want) {
+				t.Errorf("References(%q) = %q, want %q", test.expression, references, test.want)
+			}
+		})
+	}
+}
+
+func TestReferencesIn(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  []string
+	}{
+		{
+			name:  "script",
+			value: "echo \"${{ github.event.issue.title }}\"\ncurl -H \"${{ secrets.TOKEN }}\"",
+			want:  []string{"github.event.issue.title", "secrets.TOKEN"},
+		},
+		{
+			name:  "invalid expressions are skipped",
+			value: "${{ github.ref == }} ${{ github.sha }}",
+			want:  []string{"github.sha"},
+		},
+		{name: "bare opening", value: "echo ${{ github.ref", want: nil},
+		{name: "unterminated string", value: "${{ github.ref == 'main }}", want: nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if references := ReferencesIn(test.value); !slices.Equal(references, test.want) {
+				t.Errorf("ReferencesIn(%q) = %q, want %q", test.value, references, test.want)
+			}
+		})
+	}
+}
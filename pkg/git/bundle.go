@@ -0,0 +1,124 @@
+package git
+
+import (
+	"kleio/pkg/git/model"
+	"fmt"
+	"os/exec"
+	"path"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// bannerRegex matches the banners of the bundled modules (e.g., `/*! name v1.2.3 | MIT */` or
+// `@license name v1.2.3`)
+var bannerRegex = regexp.MustCompile(`(?:/\*!|@license)\s*((?:@[\w.-]+/)?[\w.-]+)\s+v?(\d+\.\d+\.\d+[\w.+-]*)`)
+
+// manifestRegex matches the `package.json` files inlined in the bundle (e.g., `{"name":"name","version":"1.2.3"`)
+var manifestRegex = regexp.MustCompile(`\\?"name\\?"\s*:\s*\\?"((?:@[\w.-]+/)?[\w.-]+)\\?"\s*,\s*\\?"version\\?"\s*:\s*\\?"(\d+\.\d+\.\d+[\w.+-]*)\\?"`)
+
+// pathRegex matches the paths of the bundled modules (e.g., `node_modules/@scope/name/lib/index.js`)
+var pathRegex = regexp.MustCompile(`node_modules/((?:@[\w.-]+/)?[\w.-]+)/`)
+
+// GetBundle returns the [Bundle] struct of the directory containing the entrypoint of a JavaScript Action at a commit
+// of its cloned repository. An entrypoint at the root of the repository is fingerprinted alone, since the root
+// directory contains the sources as well
+func GetBundle(repositoryPath string, hash string, main string) (model.Bundle, error) {
+	bundle := model.Bundle{}
+	directory := path.Dir(path.Clean(main))
+
+	if directory == "." {
+		directory = path.Clean(main)
+	}
+
+	cmd := exec.Command("git", "-C", repositoryPath, "rev-parse", fmt.Sprintf("%s:%s", hash, directory))
+	tree, err := cmd.Output()
+
+	if err != nil {
+		return bundle, err
+	}
+
+	cmd = exec.Command("git", "-C", repositoryPath, "ls-tree", "-r", "-l", hash, "--", directory)
+	out, err := cmd.Output()
+
+	if err != nil {
+		return bundle, err
+	}
+
+	size := int64(0)
+	modules := []string{}
+	named := map[string]bool{}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		// Each line is `<mode> <type> <object> <size>\t<path>`
+		info, file, ok := strings.Cut(line, "\t")
+		fields := strings.Fields(info)
+
+		if !ok || len(fields) != 4 || fields[1] != "blob" {
+			continue
+		}
+
+		if blobSize, err := strconv.ParseInt(fields[3], 10, 64); err == nil {
+			size += blobSize
+		}
+
+		if ext := path.Ext(file); ext != ".js" && ext != ".cjs" && ext != ".mjs" {
+			continue
+		}
+
+		content, err := getContent(repositoryPath, file, hash)
+
+		if err != nil {
+			continue
+		}
+
+		for _, regex := range []*regexp.Regexp{bannerRegex, manifestRegex} {
+			for _, match := range regex.FindAllStringSubmatch(content, -1) {
+				modules = append(modules, match[1]+"@"+match[2])
+				named[match[1]] = true
+			}
+		}
+
+		for _, match := range pathRegex.FindAllStringSubmatch(content, -1) {
+			modules = append(modules, match[1])
+		}
+	}
+
+	// Modules whose version is known are not listed by name as well
+	modules = slices.DeleteFunc(modules, func(module string) bool {
+		return !strings.Contains(module[1:], "@") && named[module]
+	})
+
+	slices.Sort(modules)
+	bundle.Init(directory, strings.TrimSpace(string(tree)), size, slices.Compact(modules))
+
+	return bundle, nil
+}
+
+// GetChangedFiles returns the paths of the files that differ between two commits of a cloned repository
+func GetChangedFiles(repositoryPath string, from string, to string) ([]string, error) {
+	cmd := exec.Command("git", "-C", repositoryPath, "diff", "--name-only", "--no-renames", from, to)
+	out, err := cmd.Output()
+
+	if err != nil {
+		return nil, err
+	}
+
+	files := []string{}
+
+	for _, file := range strings.Split(string(out), "\n") {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+
+	return files, nil
+}
+
+// IsAncestor returns whether a commit of a cloned repository is an ancestor of (or the same as) another one
+func IsAncestor(repositoryPath string, ancestor string, descendant string) bool {
+	cmd := exec.Command("git", "-C", repositoryPath, "merge-base", "--is-ancestor", ancestor, descendant)
+
+	return cmd.Run() == nil
+}
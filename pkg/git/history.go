@@ -2,15 +2,35 @@ package git
 
 import (
 	"kleio/pkg/git/model"
-	"encoding/json"
 	"fmt"
-	"net/http"
+	"io"
 	"os/exec"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
+// logFormat is the format passed to `git log`, with fields separated by \x1f and commits separated by \x1e
+const logFormat = "--format=%x1e%H%x1f%an%x1f%ae%x1f%aI%x1f%cn%x1f%ce%x1f%cI"
+
+// A logEntry is a commit as returned by `git log`, together with the status of the paths it touched
+type logEntry struct {
+	hash      string
+	author    model.Identity
+	committer model.Identity
+	changes   []change
+}
+
+// A change is the status of a path touched by a commit (as returned by `git log --name-status`)
+type change struct {
+	status  byte
+	oldPath string
+	path    string
+}
+
 // getContent returns the content of a file as a string given its commit hash
 func getContent(repositoryPath string, filePath string, hash string) (string, error) {
 	cmd := exec.Command("git", "-C", repositoryPath, "show", fmt.Sprintf("%s:%s", hash, filePath))
@@ -23,86 +43,231 @@ func getContent(repositoryPath string, filePath string, hash string) (string, er
 	return string(out), nil
 }
 
-// getFileHistory returns a [File] struct containing its history
-func getFileHistory(repositoryPath, path, repo, token string) (model.File, error) {
-	var commits []model.Commit
+// parseIdentity returns an [Identity] struct given the name, email, and strict ISO 8601 date of a git identity
+func parseIdentity(name, email, dateRaw string) (model.Identity, error) {
+	identity := model.Identity{}
+	date, err := time.Parse(time.RFC3339, dateRaw)
 
-	filePathSlice := strings.Split(path, "/")
-	filename := filePathSlice[len(filePathSlice)-1]
+	if err != nil {
+		return identity, err
+	}
 
-	fmt.Println("   Reading commits from \033[34m" + filename + "\033[0m workflow file")
+	identity.Init(name, email, date.UTC())
 
-	type commit struct {
-		Sha    string `json:"sha"`
-		Commit struct {
-			Committer struct {
-				Date string `json:"date"`
-			} `json:"committer"`
-		} `json:"commit"`
+	return identity, nil
+}
+
+// readLog runs `git log` with the given arguments on a repository and returns the parsed commits
+func readLog(repositoryPath string, args ...string) ([]logEntry, error) {
+	cmd := exec.Command("git", append([]string{"-C", repositoryPath, "log", logFormat, "--name-status"}, args...)...)
+	out, err := cmd.Output()
+
+	if err != nil {
+		return nil, err
 	}
 
-	commitsRaw := []commit{}
-	page := 1
+	var entries []logEntry
 
-	for {
-		uri := fmt.Sprintf("repos/%s/commits?path=%s&page=%d&per_page=100", 
-			repo, strings.ReplaceAll(path, "/", "%2F"), page)
+	for _, record := range strings.Split(string(out), "\x1e") {
+		if strings.TrimSpace(record) == "" {
+			continue
+		}
+
+		lines := strings.Split(record, "\n")
+		fields := strings.Split(lines[0], "\x1f")
 
-		client := &http.Client{}
+		if len(fields) != 7 {
+			return nil, fmt.Errorf("unexpected git log record %q", lines[0])
+		}
 
-		req, err := http.NewRequest("GET", "https://api.github.com/"+uri, nil)
+		author, err := parseIdentity(fields[1], fields[2], fields[3])
 
 		if err != nil {
-			break
+			return nil, err
 		}
 
-		req.Header.Set("Authorization", "Bearer "+token)
-		res, err := client.Do(req)
+		committer, err := parseIdentity(fields[4], fields[5], fields[6])
 
-		if res.StatusCode != 200 || err != nil {
-			fmt.Println(res.StatusCode)
-			fmt.Println(err)
-			break
+		if err != nil {
+			return nil, err
 		}
 
-		var commits []commit
-		err = json.NewDecoder(res.Body).Decode(&commits)
-		
-		if err != nil || len(commits) == 0 {
-			break
+		entry := logEntry{hash: fields[0], author: author, committer: committer}
+
+		for _, line := range lines[1:] {
+			status := strings.Split(line, "\t")
+
+			if len(status) < 2 || status[0] == "" {
+				continue
+			}
+
+			c := change{status: status[0][0], path: status[len(status)-1]}
+
+			if len(status) == 3 {
+				c.oldPath = status[1]
+			}
+
+			entry.changes = append(entry.changes, c)
 		}
 
-		commitsRaw = append(commitsRaw, commits...)
-		
-		page++
+		entries = append(entries, entry)
 	}
 
-	for _, commit := range commitsRaw {
-		date, err := time.Parse("2006-01-02T15:04:05Z", commit.Commit.Committer.Date)
+	return entries, nil
+}
 
-		if err != nil {
-			return model.File{}, err
+// A lineage is the history of a workflow file, followed across renames
+type lineage struct {
+	name     string
+	previous string
+	created  string
+	paths    []string
+	entries  []logEntry
+	commits  []string
+	deleted  *logEntry
+}
+
+// getLineages returns the lineages of all the files that ever existed in a directory of a repository, in the order
+// in which they were created
+func getLineages(repositoryPath, directory string) ([]*lineage, error) {
+	entries, err := readLog(repositoryPath, "--reverse", "-M", "--", directory)
+
+	if err != nil {
+		return nil, err
+	}
+
+	var lineages []*lineage
+	current := map[string]*lineage{}
+
+	// get returns the lineage of a path, creating it in a commit if it does not exist
+	get := func(path string, entry logEntry) *lineage {
+		if l, ok := current[path]; ok {
+			return l
 		}
 
-		content, err := getContent(repositoryPath, path, commit.Sha)
+		l := &lineage{created: entry.hash, paths: []string{path}}
+		current[path] = l
+		lineages = append(lineages, l)
+
+		return l
+	}
+
+	for _, entry := range entries {
+		for _, c := range entry.changes {
+			switch c.status {
+			case 'R':
+				l := get(c.oldPath, entry)
+				delete(current, c.oldPath)
+
+				current[c.path] = l
+				l.paths = append(l.paths, c.path)
+				l.entries = append(l.entries, entry)
+				l.commits = append(l.commits, c.path)
+			case 'D':
+				if l, ok := current[c.path]; ok {
+					l.deleted = &entry
+					delete(current, c.path)
+				}
+			case 'A', 'C':
+				// A path re-created after its deletion starts a new lineage
+				delete(current, c.path)
+				fallthrough
+			default:
+				l := get(c.path, entry)
+				l.entries = append(l.entries, entry)
+				l.commits = append(l.commits, c.path)
+			}
+		}
+	}
+
+	// Lineages are identified by their first filename, so that their identity never changes. Lineages reusing the first
+	// filename of an older lineage are disambiguated by the hash of the commit that created them
+	names := map[string]bool{}
+	previous := map[string]int{}
+
+	for _, l := range lineages {
+		l.name = filepath.Base(l.paths[0])
+
+		if names[l.name] {
+			l.name = fmt.Sprintf("%s@%s", l.name, l.created[:7])
+		}
+
+		names[l.name] = true
+
+		l.previous = filepath.Base(l.paths[len(l.paths)-1])
+		previous[l.previous]++
+	}
+
+	// Previous versions identified lineages by their latest filename, followed by the hash of the commit that deleted
+	// them if another lineage had the same filename
+	for _, l := range lineages {
+		if previous[l.previous] > 1 && l.deleted != nil {
+			l.previous = fmt.Sprintf("%s@%s", l.previous, l.deleted.hash[:7])
+		}
+	}
+
+	return lineages, nil
+}
+
+// getFileHistory returns a [File] struct containing the history of a lineage, from the latest commit to the oldest.
+// The commits whose hashes are in saved are skipped (a nil saved reads them all). Progress messages are written to out
+func getFileHistory(repositoryPath string, l *lineage, saved map[string]bool, out io.Writer) (model.File, error) {
+	var commits []model.Commit
+
+	stored := 0
+
+	path := l.paths[len(l.paths)-1]
+	filename := l.name
+
+	fmt.Fprint(out, "   Reading commits from \033[34m" + filename + "\033[0m workflow file")
+
+	if len(l.paths) > 1 {
+		fmt.Fprint(out, " \033[37m(renamed from " + strings.Join(l.paths[:len(l.paths)-1], ", ") + ")\033[0m")
+	}
+
+	if l.deleted != nil {
+		fmt.Fprint(out, " \033[37m(deleted in " + l.deleted.hash + ")\033[0m")
+	}
+
+	fmt.Fprintln(out)
+
+	for i := len(l.entries) - 1; i >= 0; i-- {
+		entry := l.entries[i]
+
+		// Commits already saved by a previous crawl are skipped whatever their date, since the commits of a merged
+		// branch can be older than the ones saved before the merge
+		if saved[entry.hash] {
+			stored++
+			continue
+		}
+
+		content, err := getContent(repositoryPath, l.commits[i], entry.hash)
 
 		if err != nil {
 			continue
 		}
 
-		fmt.Print("      Extracting components from \033[34m" +
-			commit.Sha + "\033[0m \033[37m[" + date.String() + "]\033[0m commit")
+		fmt.Fprint(out, "      Extracting components from \033[34m" +
+			entry.hash + "\033[0m \033[37m[" + entry.committer.GetDate().String() + "]\033[0m commit")
 
-		components, err := extractComponents(content)
+		// The content is parsed once, and the parsed content is shared by the extractors
+		var root yaml.Node
+
+		if err = yaml.Unmarshal([]byte(content), &root); err != nil {
+			fmt.Fprintln(out, " \033[31m𐄂\u001B[0m YAML parsing error")
+			continue
+		}
+
+		components, err := extractComponents(&root)
 
 		if err != nil {
 			return model.File{}, err
 		}
 
-		if components == nil {
-			fmt.Println(" \033[31m𐄂\u001B[0m YAML parsing error")
-			continue
-		}
+		jobs := ExtractJobs(&root)
+		triggers := ExtractTriggers(&root)
+		permissions := ExtractPermissions(&root)
+		env := ExtractEnv(&root)
 
 		acc := 0
 
@@ -110,19 +275,35 @@ func getFileHistory(repositoryPath, path, repo, token string) (model.File, error
 			acc += component.GetAllUses()
 		}
 
-		fmt.Print(" \033[32m✓\033[0m (" + strconv.Itoa(len(components)) +
+		fmt.Fprint(out, " \033[32m✓\033[0m (" + strconv.Itoa(len(components)) +
 			" components extracted / " + strconv.Itoa(acc) + " total uses)\n")
 
 		commitStruct := model.Commit{}
-		commitStruct.Init(commit.Sha, date, content, components)
+		commitStruct.Init(entry.hash, l.commits[i], entry.author, entry.committer, content, components)
+		commitStruct.SetJobs(jobs)
+		commitStruct.SetTriggers(triggers)
+		commitStruct.SetPermissions(permissions)
+		commitStruct.SetEnv(env)
 
 		commits = append(commits, commitStruct)
 	}
 
-	fmt.Println("   \033[32m✓\033[0m (" + strconv.Itoa(len(commits)) + " commits extracted)\n")
+	if stored > 0 {
+		fmt.Fprintln(out, "   \033[32m✓\033[0m (" + strconv.Itoa(len(commits)) + " new commits extracted, " +
+			strconv.Itoa(stored) + " already saved)\n")
+	} else {
+		fmt.Fprintln(out, "   \033[32m✓\033[0m (" + strconv.Itoa(len(commits)) + " commits extracted)\n")
+	}
 
 	fileStruct := model.File{}
 	fileStruct.Init(filename, path, commits, nil)
+	fileStruct.SetPaths(l.paths)
+	fileStruct.SetCreation(l.created)
+	fileStruct.SetPreviousFilename(l.previous)
+
+	if l.deleted != nil {
+		fileStruct.MarkDeleted(l.deleted.hash, l.deleted.committer.GetDate())
+	}
 
 	return fileStruct, nil
 }
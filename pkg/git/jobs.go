@@ -0,0 +1,257 @@
+package git
+
+import (
+	"kleio/pkg/expr"
+	"kleio/pkg/git/model"
+	"maps"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExtractJobs returns the jobs (and their steps) defined in a workflow, in the order they are defined. Jobs that do not
+// declare their permissions inherit the top-level ones of the workflow, steps receive the secrets passed to them
+// through their inputs, their scripts, or the environment variables of the step, job, or workflow, and jobs calling a
+// reusable workflow receive the secrets they pass to it. The workflow is given by its parsed content
+func ExtractJobs(root *yaml.Node) []*model.Job {
+	jobs := []*model.Job{}
+	jobsNode := mappingValue(documentContent(root), "jobs")
+	permissions := ExtractPermissions(root)
+	workflowEnv := ExtractEnv(root)
+
+	if jobsNode == nil || jobsNode.Kind != yaml.MappingNode {
+		return jobs
+	}
+
+	for i := 0; i+1 < len(jobsNode.Content); i += 2 {
+		id := jobsNode.Content[i].Value
+		jobNode := jobsNode.Content[i+1]
+		jobEnv := scalarMap(mappingValue(jobNode, "env"))
+
+		steps := []*model.Step{}
+
+		if stepsNode := mappingValue(jobNode, "steps"); stepsNode != nil && stepsNode.Kind == yaml.SequenceNode {
+			for index, stepNode := range stepsNode.Content {
+				step := model.Step{}
+				step.Init(
+					index,
+					scalar(mappingValue(stepNode, "id")),
+					scalar(mappingValue(stepNode, "name")),
+					scalar(mappingValue(stepNode, "uses")),
+					scalar(mappingValue(stepNode, "run")),
+					scalar(mappingValue(stepNode, "if")),
+					scalarMap(mappingValue(stepNode, "with")),
+					scalarMap(mappingValue(stepNode, "env")),
+				)
+
+				step.SetSecrets(receivedSecrets(&step, jobEnv, workflowEnv))
+
+				steps = append(steps, &step)
+			}
+		}
+
+		job := model.Job{}
+		job.Init(
+			id,
+			scalar(mappingValue(jobNode, "name")),
+			runnerLabels(mappingValue(jobNode, "runs-on")),
+			scalarList(mappingValue(jobNode, "needs")),
+			scalar(mappingValue(jobNode, "if")),
+			scalar(mappingValue(jobNode, "uses")),
+			scalarMap(mappingValue(jobNode, "with")),
+			scalarMap(mappingValue(jobNode, "env")),
+			steps,
+		)
+
+		job.SetSecrets(passedSecrets(mappingValue(jobNode, "secrets")))
+
+		if permissionsNode := mappingValue(jobNode, "permissions"); permissionsNode != nil {
+			job.SetPermissions(parsePermissions(permissionsNode))
+		} else {
+			job.SetPermissions(permissions)
+		}
+
+		jobs = append(jobs, &job)
+	}
+
+	return jobs
+}
+
+// ExtractEnv returns the top-level environment variables of a workflow, given its parsed content
+func ExtractEnv(root *yaml.Node) map[string]string {
+	return scalarMap(mappingValue(documentContent(root), "env"))
+}
+
+// receivedSecrets returns the secrets received by a step, keyed by the input, environment variable, or script they
+// are passed through. Environment variables of the step override the ones of its job, which override the ones of
+// the workflow
+func receivedSecrets(step *model.Step, jobEnv map[string]string, workflowEnv map[string]string) map[string][]string {
+	received := map[string][]string{}
+
+	env := map[string]string{}
+	maps.Copy(env, workflowEnv)
+	maps.Copy(env, jobEnv)
+	maps.Copy(env, step.GetEnv())
+
+	channels := map[string]string{"run": step.GetRun()}
+
+	for name, value := range step.GetWith() {
+		channels["with."+name] = value
+	}
+
+	for name, value := range env {
+		channels["env."+name] = value
+	}
+
+	for channel, value := range channels {
+		if secrets := secretsOf(value); len(secrets) > 0 {
+			received[channel] = secrets
+		}
+	}
+
+	return received
+}
+
+// passedSecrets returns the secrets passed by a job to the reusable workflow it calls, either through the `secrets`
+// mapping (keyed by `secrets.<name>`) or all of them with `secrets: inherit` (keyed by `secrets`, as `*`)
+func passedSecrets(node *yaml.Node) map[string][]string {
+	passed := map[string][]string{}
+
+	if node == nil {
+		return passed
+	}
+
+	if node.Kind == yaml.ScalarNode && node.Value == "inherit" {
+		passed["secrets"] = []string{"*"}
+		return passed
+	}
+
+	for name, value := range scalarMap(node) {
+		if secrets := secretsOf(value); len(secrets) > 0 {
+			passed["secrets."+name] = secrets
+		}
+	}
+
+	return passed
+}
+
+// secretsOf returns the names of the secrets referenced by the expressions of a value. The GITHUB_TOKEN is a secret as
+// well, and the whole `secrets` context (e.g., in `toJSON(secrets)`) is returned as `*`
+func secretsOf(value string) []string {
+	secrets := []string{}
+
+	for _, reference := range expr.ReferencesIn(value) {
+		switch {
+		case reference == "secrets":
+			secrets = append(secrets, "*")
+		case strings.HasPrefix(reference, "secrets."):
+			// Secret names are case-insensitive
+			secrets = append(secrets, strings.ToUpper(strings.SplitN(reference, ".", 3)[1]))
+		case reference == "github.token":
+			secrets = append(secrets, "GITHUB_TOKEN")
+		}
+	}
+
+	return secrets
+}
+
+// documentContent returns the root node of a YAML document
+func documentContent(node *yaml.Node) *yaml.Node {
+	if node.Kind == yaml.DocumentNode && len(node.Content) > 0 {
+		return node.Content[0]
+	}
+
+	return node
+}
+
+// mappingValue returns the value of a key of a mapping node, or nil if the node is not a mapping or the key is missing
+func mappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			value := node.Content[i+1]
+
+			if value.Kind == yaml.AliasNode && value.Alias != nil {
+				return value.Alias
+			}
+
+			return value
+		}
+	}
+
+	return nil
+}
+
+// scalar returns the value of a scalar node. Mappings and sequences are rendered in flow style
+func scalar(node *yaml.Node) string {
+	if node == nil {
+		return ""
+	}
+
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+
+	copied := *node
+	copied.Style = yaml.FlowStyle
+	out, err := yaml.Marshal(&copied)
+
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(out))
+}
+
+// scalarList returns the values of a sequence node, or the value of a scalar node as a single element
+func scalarList(node *yaml.Node) []string {
+	values := []string{}
+
+	if node == nil {
+		return values
+	}
+
+	if node.Kind != yaml.SequenceNode {
+		return append(values, scalar(node))
+	}
+
+	for _, item := range node.Content {
+		values = append(values, scalar(item))
+	}
+
+	return values
+}
+
+// scalarMap returns the values of a mapping node by key
+func scalarMap(node *yaml.Node) map[string]string {
+	values := map[string]string{}
+
+	if node == nil || node.Kind != yaml.MappingNode {
+		return values
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		values[node.Content[i].Value] = scalar(node.Content[i+1])
+	}
+
+	return values
+}
+
+// runnerLabels returns the labels of the runners of a job. The `runs-on` key is either a label, a list of labels, or
+// a mapping with a runner group and its labels (the group is returned as `group:<name>`)
+func runnerLabels(node *yaml.Node) []string {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return scalarList(node)
+	}
+
+	labels := scalarList(mappingValue(node, "labels"))
+
+	if group := mappingValue(node, "group"); group != nil {
+		labels = append(labels, "group:"+scalar(group))
+	}
+
+	return labels
+}
@@ -0,0 +1,95 @@
+package git
+
+import (
+	"kleio/pkg/docker"
+	"kleio/pkg/git/model"
+	"errors"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ErrNoManifest is returned when a commit of an Action does not contain an `action.yml` or `action.yaml` file in the
+// directory of the Action
+var ErrNoManifest = errors.New("no action.yml found")
+
+// GetManifest returns the [Manifest] struct of an Action at a commit of its cloned repository, given the directory of
+// the Action in the repository (`.` for the Actions at its root). The base images of the Dockerfile built by Docker
+// Actions, whose path is relative to the manifest, are added to its components
+func GetManifest(repositoryPath string, directory string, hash string) (model.Manifest, error) {
+	for _, filename := range []string{"action.yml", "action.yaml"} {
+		content, err := getContent(repositoryPath, path.Join(directory, filename), hash)
+
+		if err != nil {
+			continue
+		}
+
+		manifest, err := ExtractManifest(content)
+
+		if err != nil || manifest.GetUsing() != "docker" || manifest.GetImage() == "" || strings.HasPrefix(manifest.GetImage(), "docker://") {
+			return manifest, err
+		}
+
+		if dockerfile, err := getContent(repositoryPath, path.Join(directory, manifest.GetImage()), hash); err == nil {
+			components := map[string]*model.Component{}
+
+			for _, image := range docker.BaseImages(dockerfile) {
+				buildComponent("docker", ":", image.String(), components)
+			}
+
+			manifest.AddComponents(slices.Collect(maps.Values(components))...)
+		}
+
+		return manifest, nil
+	}
+
+	return model.Manifest{}, ErrNoManifest
+}
+
+// ExtractManifest returns the [Manifest] struct of the content of an `action.yml` file. Only the Actions and Docker
+// images used by other repositories are returned as components, since local Actions (`./path`) are part of the Action
+func ExtractManifest(content string) (model.Manifest, error) {
+	var root yaml.Node
+
+	manifest := model.Manifest{}
+
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+		return manifest, err
+	}
+
+	runs := mappingValue(documentContent(&root), "runs")
+	image := scalar(mappingValue(runs, "image"))
+	components := map[string]*model.Component{}
+
+	if reference, ok := strings.CutPrefix(image, "docker://"); ok {
+		buildComponent("docker", ":", reference, components)
+	}
+
+	if steps := mappingValue(runs, "steps"); steps != nil && steps.Kind == yaml.SequenceNode {
+		for _, step := range steps.Content {
+			uses := scalar(mappingValue(step, "uses"))
+
+			switch {
+			case uses == "" || strings.HasPrefix(uses, "./"):
+				continue
+			case strings.HasPrefix(uses, "docker://"):
+				buildComponent("docker", ":", strings.TrimPrefix(uses, "docker://"), components)
+			default:
+				buildComponent("action", "@", uses, components)
+			}
+		}
+	}
+
+	manifest.Init(
+		scalar(mappingValue(documentContent(&root), "name")),
+		strings.ToLower(scalar(mappingValue(runs, "using"))),
+		scalar(mappingValue(runs, "main")),
+		image,
+		slices.Collect(maps.Values(components)),
+	)
+
+	return manifest, nil
+}
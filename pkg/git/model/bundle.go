@@ -0,0 +1,44 @@
+package model
+
+// ============
+// == BUNDLE ==
+// ============
+
+// A Bundle is the compiled code shipped by a JavaScript Action (e.g., the `dist` directory built by ncc, or the
+// entrypoint alone if it is at the root of the repository) at a commit
+type Bundle struct {
+	path    string
+	hash    string
+	size    int64
+	modules []string
+}
+
+// Init initializes the [Bundle] struct
+func (b *Bundle) Init(path string, hash string, size int64, modules []string) {
+	b.path = path
+	b.hash = hash
+	b.size = size
+	b.modules = modules
+}
+
+// GetPath returns the path of the directory (or of the entrypoint) of the [Bundle] struct
+func (b *Bundle) GetPath() string {
+	return b.path
+}
+
+// GetHash returns the git tree hash of the directory (or the blob hash of the entrypoint) of the [Bundle] struct,
+// which changes whenever any of its files changes
+func (b *Bundle) GetHash() string {
+	return b.hash
+}
+
+// GetSize returns the total size (in bytes) of the files of the [Bundle] struct
+func (b *Bundle) GetSize() int64 {
+	return b.size
+}
+
+// GetModules returns the bundled modules identified in the [Bundle] struct, as `name@version` (or `name` when the
+// version is unknown)
+func (b *Bundle) GetModules() []string {
+	return b.modules
+}
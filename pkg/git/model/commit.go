@@ -9,51 +9,149 @@ import (
 // == FILE ==
 // ==========
 
-// A File that contains a list of commits (version)
+// A File that contains a list of commits (version). Since files can be renamed, a File contains all the paths it had
+// throughout its history, and it is marked as deleted if it does not exist anymore. Its filename identifies it, and
+// does not change when it is renamed or when another file reuses its name
 type File struct {
-	filename string
-	filepath string
-	history  []Commit
+	filename         string
+	filepath         string
+	paths            []string
+	history          []Commit
+	createdHash      string
+	previousFilename string
+	deletedHash      string
+	deletedDate      time.Time
 }
 
 // Init initializes the [File] struct
 func (f *File) Init(filename string, filepath string, history []Commit, components []Component) {
 	f.filename = filename
 	f.filepath = filepath
+	f.paths = []string{filepath}
 	f.history = history
 }
 
+// SetPaths sets all the paths the [File] struct had throughout its history
+func (f *File) SetPaths(paths []string) {
+	f.paths = paths
+}
+
+// SetCreation sets the hash of the commit that created the [File] struct
+func (f *File) SetCreation(hash string) {
+	f.createdHash = hash
+}
+
+// GetCreation returns the hash of the commit that created the [File] struct
+func (f *File) GetCreation() string {
+	return f.createdHash
+}
+
+// SetPreviousFilename sets the filename identifying the [File] struct in previous versions of Kleio (its latest
+// filename, followed by the hash of the commit that deleted it if another file reused it)
+func (f *File) SetPreviousFilename(filename string) {
+	f.previousFilename = filename
+}
+
+// GetPreviousFilename returns the filename identifying the [File] struct in previous versions of Kleio
+func (f *File) GetPreviousFilename() string {
+	return f.previousFilename
+}
+
+// MarkDeleted marks the [File] struct as deleted by the commit with the given hash and date
+func (f *File) MarkDeleted(hash string, date time.Time) {
+	f.deletedHash = hash
+	f.deletedDate = date
+}
+
 // GetFilename returns the filename of a [File] struct
 func (f *File) GetFilename() string {
 	return f.filename
 }
 
-// GetFilepath returns the filepath of a [File] struct
+// GetFilepath returns the filepath of a [File] struct (i.e., its latest path)
 func (f *File) GetFilepath() string {
 	return f.filepath
 }
 
+// GetPaths returns all the paths of a [File] struct, from the oldest to the latest
+func (f *File) GetPaths() []string {
+	return f.paths
+}
+
+// IsDeleted returns whether the [File] struct was deleted
+func (f *File) IsDeleted() bool {
+	return f.deletedHash != ""
+}
+
+// GetDeletion returns the hash and date of the commit that deleted the [File] struct
+func (f *File) GetDeletion() (string, time.Time) {
+	return f.deletedHash, f.deletedDate
+}
+
 // GetHistory returns the complete history of a [File] struct
 func (f *File) GetHistory() []Commit {
 	return f.history
 }
 
+// ==============
+// == IDENTITY ==
+// ==============
+
+// An Identity is the name, email, and date of either the author or the committer of a [Commit] struct
+type Identity struct {
+	name  string
+	email string
+	date  time.Time
+}
+
+// Init initializes the [Identity] struct
+func (i *Identity) Init(name string, email string, date time.Time) {
+	i.name = name
+	i.email = email
+	i.date = date
+}
+
+// GetName returns the name of the [Identity] struct
+func (i *Identity) GetName() string {
+	return i.name
+}
+
+// GetEmail returns the email of the [Identity] struct
+func (i *Identity) GetEmail() string {
+	return i.email
+}
+
+// GetDate returns the date of the [Identity] struct
+func (i *Identity) GetDate() time.Time {
+	return i.date
+}
+
 // ============
 // == COMMIT ==
 // ============
 
-// A Commit contains the commit hash, date, and content (in base64) of a specific git commit
+// A Commit contains the commit hash, path, date, and content (in base64) of a specific git commit
 type Commit struct {
-	hash       string
-	date       time.Time
-	content    string
-	components []*Component
+	hash        string
+	path        string
+	date        time.Time
+	author      Identity
+	committer   Identity
+	content     string
+	components  []*Component
+	jobs        []*Job
+	triggers    []*Trigger
+	permissions Permissions
+	env         map[string]string
 }
 
-// Init initializes the [Commit] struct
-func (c *Commit) Init(hash string, date time.Time, content string, components []*Component) {
+// Init initializes the [Commit] struct. The date of the commit is the date of its committer
+func (c *Commit) Init(hash string, path string, author Identity, committer Identity, content string, components []*Component) {
 	c.hash = hash
-	c.date = date
+	c.path = path
+	c.date = committer.GetDate()
+	c.author = author
+	c.committer = committer
 	c.content = base64.StdEncoding.EncodeToString([]byte(content))
 	c.components = components
 }
@@ -63,11 +161,26 @@ func (c *Commit) GetHash() string {
 	return c.hash
 }
 
+// GetPath returns the path the file had in a [Commit] struct
+func (c *Commit) GetPath() string {
+	return c.path
+}
+
 // GetDate returns the date of a [Commit] struct
 func (c *Commit) GetDate() time.Time {
 	return c.date
 }
 
+// GetAuthor returns the author of a [Commit] struct
+func (c *Commit) GetAuthor() Identity {
+	return c.author
+}
+
+// GetCommitter returns the committer of a [Commit] struct
+func (c *Commit) GetCommitter() Identity {
+	return c.committer
+}
+
 // GetContent returns either the decoded or the base64 encoded content of a [Commit] struct
 func (c *Commit) GetContent(decode bool) (string, error) {
 	if decode {
@@ -85,3 +198,43 @@ func (c *Commit) GetContent(decode bool) (string, error) {
 func (c *Commit) GetComponents() []*Component {
 	return c.components
 }
+
+// SetJobs sets the jobs defined in the [Commit] struct
+func (c *Commit) SetJobs(jobs []*Job) {
+	c.jobs = jobs
+}
+
+// GetJobs returns the jobs defined in the [Commit] struct
+func (c *Commit) GetJobs() []*Job {
+	return c.jobs
+}
+
+// SetTriggers sets the events triggering the workflow in the [Commit] struct
+func (c *Commit) SetTriggers(triggers []*Trigger) {
+	c.triggers = triggers
+}
+
+// GetTriggers returns the events triggering the workflow in the [Commit] struct
+func (c *Commit) GetTriggers() []*Trigger {
+	return c.triggers
+}
+
+// SetPermissions sets the top-level permissions of the workflow in the [Commit] struct
+func (c *Commit) SetPermissions(permissions Permissions) {
+	c.permissions = permissions
+}
+
+// GetPermissions returns the top-level permissions of the workflow in the [Commit] struct
+func (c *Commit) GetPermissions() Permissions {
+	return c.permissions
+}
+
+// SetEnv sets the top-level environment variables of the workflow in the [Commit] struct
+func (c *Commit) SetEnv(env map[string]string) {
+	c.env = env
+}
+
+// GetEnv returns the top-level environment variables of the workflow in the [Commit] struct
+func (c *Commit) GetEnv() map[string]string {
+	return c.env
+}
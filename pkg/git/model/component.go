@@ -70,7 +70,7 @@ func (v *Version) Init(versionString string) {
 
 	majorRegex := regexp.MustCompile(`^([vV])?\d+$`)
 	completeRegex := regexp.MustCompile(`^([vV])?(0|[1-9]\d*)\.?(0|[1-9]\d*)?\.?(0|[1-9]\d*)?(?:-((?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*)(?:\.(?:0|[1-9]\d*|\d*[a-zA-Z-][0-9a-zA-Z-]*))*))?(?:\+([0-9a-zA-Z-]+(?:\.[0-9a-zA-Z-]+)*))?$`)
-	hash := regexp.MustCompile(`^(sha256:)?.{40}$`)
+	hash := regexp.MustCompile(`^(sha256:[a-fA-F0-9]{64}|.{40})$`)
 
 	if majorRegex.MatchString(versionString) {
 		v.versionType = "major"
@@ -0,0 +1,181 @@
+package model
+
+// =========
+// == JOB ==
+// =========
+
+// A Job of a workflow, together with its steps. Jobs calling a reusable workflow have no steps, but use the workflow
+type Job struct {
+	id          string
+	name        string
+	runsOn      []string
+	needs       []string
+	condition   string
+	uses        string
+	with        map[string]string
+	env         map[string]string
+	steps       []*Step
+	permissions Permissions
+	secrets     map[string][]string
+}
+
+// Init initializes the [Job] struct
+func (j *Job) Init(id string, name string, runsOn []string, needs []string, condition string, uses string, with map[string]string, env map[string]string, steps []*Step) {
+	j.id = id
+	j.name = name
+	j.runsOn = runsOn
+	j.needs = needs
+	j.condition = condition
+	j.uses = uses
+	j.with = with
+	j.env = env
+	j.steps = steps
+}
+
+// GetId returns the id (i.e., the key in the `jobs` mapping) of the [Job] struct
+func (j *Job) GetId() string {
+	return j.id
+}
+
+// GetName returns the name of the [Job] struct
+func (j *Job) GetName() string {
+	return j.name
+}
+
+// GetRunsOn returns the labels of the runners the [Job] struct runs on
+func (j *Job) GetRunsOn() []string {
+	return j.runsOn
+}
+
+// GetNeeds returns the ids of the jobs the [Job] struct depends on
+func (j *Job) GetNeeds() []string {
+	return j.needs
+}
+
+// GetCondition returns the `if` condition of the [Job] struct
+func (j *Job) GetCondition() string {
+	return j.condition
+}
+
+// GetUses returns the reusable workflow called by the [Job] struct
+func (j *Job) GetUses() string {
+	return j.uses
+}
+
+// GetWith returns the inputs passed to the reusable workflow called by the [Job] struct
+func (j *Job) GetWith() map[string]string {
+	return j.with
+}
+
+// GetEnv returns the environment variables of the [Job] struct
+func (j *Job) GetEnv() map[string]string {
+	return j.env
+}
+
+// GetSteps returns the steps of the [Job] struct
+func (j *Job) GetSteps() []*Step {
+	return j.steps
+}
+
+// SetPermissions sets the permissions of the [Job] struct
+func (j *Job) SetPermissions(permissions Permissions) {
+	j.permissions = permissions
+}
+
+// GetPermissions returns the permissions of the [Job] struct
+func (j *Job) GetPermissions() Permissions {
+	return j.permissions
+}
+
+// SetSecrets sets the secrets passed by the [Job] struct to the reusable workflow it calls, keyed by the secret of the
+// workflow (`secrets.<name>`) they are passed as, or by `secrets` when all of them are inherited
+func (j *Job) SetSecrets(secrets map[string][]string) {
+	j.secrets = secrets
+}
+
+// GetSecrets returns the secrets passed by the [Job] struct to the reusable workflow it calls, keyed by the secret of
+// the workflow they are passed as
+func (j *Job) GetSecrets() map[string][]string {
+	return j.secrets
+}
+
+// ==========
+// == STEP ==
+// ==========
+
+// A Step of a [Job] struct, which either uses an Action (or Docker image) or runs a script
+type Step struct {
+	index     int
+	id        string
+	name      string
+	uses      string
+	run       string
+	condition string
+	with      map[string]string
+	env       map[string]string
+	secrets   map[string][]string
+}
+
+// Init initializes the [Step] struct
+func (s *Step) Init(index int, id string, name string, uses string, run string, condition string, with map[string]string, env map[string]string) {
+	s.index = index
+	s.id = id
+	s.name = name
+	s.uses = uses
+	s.run = run
+	s.condition = condition
+	s.with = with
+	s.env = env
+}
+
+// GetIndex returns the position of the [Step] struct in its job
+func (s *Step) GetIndex() int {
+	return s.index
+}
+
+// GetId returns the id of the [Step] struct
+func (s *Step) GetId() string {
+	return s.id
+}
+
+// GetName returns the name of the [Step] struct
+func (s *Step) GetName() string {
+	return s.name
+}
+
+// GetUses returns the Action or Docker image used by the [Step] struct
+func (s *Step) GetUses() string {
+	return s.uses
+}
+
+// GetRun returns the script run by the [Step] struct
+func (s *Step) GetRun() string {
+	return s.run
+}
+
+// GetCondition returns the `if` condition of the [Step] struct
+func (s *Step) GetCondition() string {
+	return s.condition
+}
+
+// GetWith returns the inputs passed to the Action used by the [Step] struct
+func (s *Step) GetWith() map[string]string {
+	return s.with
+}
+
+// GetEnv returns the environment variables of the [Step] struct
+func (s *Step) GetEnv() map[string]string {
+	return s.env
+}
+
+// SetSecrets sets the secrets received by the [Step] struct, keyed by the input (`with.<name>`), environment variable
+// (`env.<name>`), or script (`run`) they are passed through
+func (s *Step) SetSecrets(secrets map[string][]string) {
+	s.secrets = secrets
+}
+
+// GetSecrets returns the secrets received by the [Step] struct, keyed by the input, environment variable, or script
+// they are passed through
+func (s *Step) GetSecrets() map[string][]string {
+	return s.secrets
+}
@@ -0,0 +1,69 @@
+package model
+
+import "strings"
+
+// ==============
+// == MANIFEST ==
+// ==============
+
+// A Manifest is the metadata file (`action.yml` or `action.yaml`) of an Action at a given commit
+type Manifest struct {
+	name       string
+	using      string
+	main       string
+	image      string
+	components []*Component
+}
+
+// Init initializes the [Manifest] struct
+func (m *Manifest) Init(name string, using string, main string, image string, components []*Component) {
+	m.name = name
+	m.using = using
+	m.main = main
+	m.image = image
+	m.components = components
+}
+
+// GetName returns the name of the Action described by the [Manifest] struct
+func (m *Manifest) GetName() string {
+	return m.name
+}
+
+// GetUsing returns the runtime of the Action described by the [Manifest] struct (i.e., `runs.using`, such as
+// `node20`, `docker`, or `composite`)
+func (m *Manifest) GetUsing() string {
+	return m.using
+}
+
+// GetSubtype returns the kind of the Action described by the [Manifest] struct given its runtime: `javascript` (for
+// any `nodeXX` runtime), `docker`, or `composite`. Unknown runtimes are returned as they are
+func (m *Manifest) GetSubtype() string {
+	if strings.HasPrefix(m.using, "node") {
+		return "javascript"
+	}
+
+	return m.using
+}
+
+// GetMain returns the entrypoint (`runs.main`) of the JavaScript Action described by the [Manifest] struct (e.g.,
+// `dist/index.js`)
+func (m *Manifest) GetMain() string {
+	return m.main
+}
+
+// GetImage returns the Docker image (`runs.image`) of the Action described by the [Manifest] struct, either a
+// `docker://` reference or the path of a Dockerfile
+func (m *Manifest) GetImage() string {
+	return m.image
+}
+
+// AddComponents adds components (e.g., the base images of the Dockerfile of a Docker Action) to the [Manifest] struct
+func (m *Manifest) AddComponents(components ...*Component) {
+	m.components = append(m.components, components...)
+}
+
+// GetComponents returns the Actions and Docker images used by the steps of a composite Action, and the Docker image
+// run by a Docker Action (or the base images of its Dockerfile)
+func (m *Manifest) GetComponents() []*Component {
+	return m.components
+}
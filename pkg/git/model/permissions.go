@@ -0,0 +1,85 @@
+package model
+
+import "maps"
+
+// =================
+// == PERMISSIONS ==
+// =================
+
+// The levels of access of the GITHUB_TOKEN to a scope
+const (
+	PermissionNone  = "none"
+	PermissionRead  = "read"
+	PermissionWrite = "write"
+)
+
+// PermissionScopes are the scopes the GITHUB_TOKEN can be granted access to
+var PermissionScopes = []string{
+	"actions", "attestations", "checks", "contents", "deployments", "discussions", "id-token", "issues", "models",
+	"packages", "pages", "pull-requests", "repository-projects", "security-events", "statuses",
+}
+
+// Permissions are the levels of access of the GITHUB_TOKEN, by scope. Permissions that are not declared are the
+// default ones of the repository, which are assumed to be the permissive ones (write access to all the scopes, except
+// `id-token` and `models`), since the actual default cannot be known from the workflow
+type Permissions struct {
+	declared bool
+	scopes   map[string]string
+}
+
+// Init initializes the [Permissions] struct. Scopes missing from a declared [Permissions] struct have no access
+func (p *Permissions) Init(declared bool, scopes map[string]string) {
+	p.declared = declared
+	p.scopes = map[string]string{}
+
+	for _, scope := range PermissionScopes {
+		switch {
+		case !declared && (scope == "id-token" || scope == "models"):
+			p.scopes[scope] = PermissionNone
+		case !declared:
+			p.scopes[scope] = PermissionWrite
+		case scopes[scope] != "":
+			p.scopes[scope] = scopes[scope]
+		default:
+			p.scopes[scope] = PermissionNone
+		}
+	}
+
+	// Scopes not known yet are kept as they are
+	for scope, level := range scopes {
+		if _, ok := p.scopes[scope]; !ok {
+			p.scopes[scope] = level
+		}
+	}
+}
+
+// IsDeclared returns whether the [Permissions] struct was declared, or is the default of the repository
+func (p *Permissions) IsDeclared() bool {
+	return p.declared
+}
+
+// GetScopes returns the level of access of each scope of the [Permissions] struct
+func (p *Permissions) GetScopes() map[string]string {
+	return maps.Clone(p.scopes)
+}
+
+// GetLevel returns the level of access of a scope of the [Permissions] struct
+func (p *Permissions) GetLevel(scope string) string {
+	if level, ok := p.scopes[scope]; ok {
+		return level
+	}
+
+	return PermissionNone
+}
+
+// PermissionRank returns the rank of a level of access, so that levels can be compared (none < read < write)
+func PermissionRank(level string) int {
+	switch level {
+	case PermissionWrite:
+		return 2
+	case PermissionRead:
+		return 1
+	default:
+		return 0
+	}
+}
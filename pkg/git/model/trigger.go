@@ -0,0 +1,62 @@
+package model
+
+// =============
+// == TRIGGER ==
+// =============
+
+// A Trigger is an event (in the `on` key) that runs a workflow, together with its filters
+type Trigger struct {
+	event     string
+	types     []string
+	branches  []string
+	paths     []string
+	schedules []string
+	workflows []string
+	inputs    []string
+}
+
+// Init initializes the [Trigger] struct
+func (t *Trigger) Init(event string, types []string, branches []string, paths []string, schedules []string, workflows []string, inputs []string) {
+	t.event = event
+	t.types = types
+	t.branches = branches
+	t.paths = paths
+	t.schedules = schedules
+	t.workflows = workflows
+	t.inputs = inputs
+}
+
+// GetEvent returns the name of the event of the [Trigger] struct (e.g., `pull_request_target`)
+func (t *Trigger) GetEvent() string {
+	return t.event
+}
+
+// GetTypes returns the activity types filtering the [Trigger] struct
+func (t *Trigger) GetTypes() []string {
+	return t.types
+}
+
+// GetBranches returns the branches (or tags) filtering the [Trigger] struct. Ignored branches are prefixed with `!`
+func (t *Trigger) GetBranches() []string {
+	return t.branches
+}
+
+// GetPaths returns the paths filtering the [Trigger] struct. Ignored paths are prefixed with `!`
+func (t *Trigger) GetPaths() []string {
+	return t.paths
+}
+
+// GetSchedules returns the cron expressions of a `schedule` [Trigger] struct
+func (t *Trigger) GetSchedules() []string {
+	return t.schedules
+}
+
+// GetWorkflows returns the workflows whose runs trigger a `workflow_run` [Trigger] struct
+func (t *Trigger) GetWorkflows() []string {
+	return t.workflows
+}
+
+// GetInputs returns the names of the inputs of a `workflow_dispatch` or `workflow_call` [Trigger] struct
+func (t *Trigger) GetInputs() []string {
+	return t.inputs
+}
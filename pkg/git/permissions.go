@@ -0,0 +1,59 @@
+package git
+
+import (
+	"kleio/pkg/git/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExtractPermissions returns the top-level permissions of a workflow, given its parsed content
+func ExtractPermissions(root *yaml.Node) model.Permissions {
+	return parsePermissions(mappingValue(documentContent(root), "permissions"))
+}
+
+// parsePermissions returns the permissions declared in a `permissions` key, which is either `read-all`, `write-all`,
+// or a mapping of scopes to their level of access (where `{}` grants no access at all). A missing key means the
+// default permissions of the repository
+func parsePermissions(node *yaml.Node) model.Permissions {
+	permissions := model.Permissions{}
+
+	if node == nil {
+		permissions.Init(false, nil)
+
+		return permissions
+	}
+
+	scopes := map[string]string{}
+
+	switch node.Kind {
+	case yaml.MappingNode:
+		scopes = scalarMap(node)
+	case yaml.ScalarNode:
+		level := ""
+
+		switch node.Value {
+		case "read-all":
+			level = model.PermissionRead
+		case "write-all":
+			level = model.PermissionWrite
+		}
+
+		for _, scope := range model.PermissionScopes {
+			switch {
+			case level == "":
+				// Expressions cannot be evaluated statically
+			case scope == "id-token" && level == model.PermissionRead:
+				// The `id-token` scope cannot be granted read access
+			case scope == "models":
+				// The `models` scope cannot be granted write access
+				scopes[scope] = model.PermissionRead
+			default:
+				scopes[scope] = level
+			}
+		}
+	}
+
+	permissions.Init(true, scopes)
+
+	return permissions
+}
@@ -0,0 +1,98 @@
+package git
+
+import (
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// GetRefs returns the commit hashes of all the tags (lightweight and annotated, peeled to the commit they point to)
+// and branches of a cloned repository, keyed by their short name
+func GetRefs(repositoryPath string) (tags map[string]string, branches map[string]string, err error) {
+	cmd := exec.Command(
+		"git", "-C", repositoryPath, "for-each-ref",
+		"--format=%(refname)%09%(objectname)%09%(*objectname)",
+		"refs/tags", "refs/remotes/origin", "refs/heads",
+	)
+	out, err := cmd.Output()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tags = map[string]string{}
+	branches = map[string]string{}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		fields := strings.Split(line, "\t")
+
+		if len(fields) != 3 {
+			continue
+		}
+
+		// Annotated tags are peeled to the commit they point to
+		hash := fields[1]
+
+		if fields[2] != "" {
+			hash = fields[2]
+		}
+
+		if name, ok := strings.CutPrefix(fields[0], "refs/tags/"); ok {
+			tags[name] = hash
+		} else if name, ok := strings.CutPrefix(fields[0], "refs/remotes/origin/"); ok && name != "HEAD" {
+			branches[name] = hash
+		} else if name, ok := strings.CutPrefix(fields[0], "refs/heads/"); ok {
+			branches[name] = hash
+		}
+	}
+
+	return tags, branches, nil
+}
+
+// GetRemoteTags returns the commit hashes of all the tags (peeled to the commit they point to) of a remote repository,
+// keyed by their short name, without cloning it
+func GetRemoteTags(url string) (map[string]string, error) {
+	cmd := exec.Command("git", "ls-remote", "--tags", url)
+	out, err := cmd.Output()
+
+	if err != nil {
+		return nil, err
+	}
+
+	tags := map[string]string{}
+
+	for _, line := range strings.Split(strings.TrimSpace(string(out)), "\n") {
+		hash, ref, ok := strings.Cut(line, "\t")
+
+		if !ok {
+			continue
+		}
+
+		name, ok := strings.CutPrefix(ref, "refs/tags/")
+
+		if !ok {
+			continue
+		}
+
+		// Annotated tags are listed twice, and the peeled entry (`<tag>^{}`) gives the commit they point to
+		if peeled, ok := strings.CutSuffix(name, "^{}"); ok {
+			tags[peeled] = hash
+		} else if _, ok := tags[name]; !ok {
+			tags[name] = hash
+		}
+	}
+
+	return tags, nil
+}
+
+// GetCommitDate returns the committer date of a commit of a cloned repository
+func GetCommitDate(repositoryPath string, hash string) (time.Time, error) {
+	cmd := exec.Command("git", "-C", repositoryPath, "show", "-s", "--format=%cI", hash)
+	out, err := cmd.Output()
+
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Parse(time.RFC3339, strings.TrimSpace(string(out)))
+}
@@ -0,0 +1,85 @@
+package git
+
+import (
+	"kleio/pkg/git/model"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ExtractTriggers returns the events (and their filters) triggering a workflow, in the order they are defined. The
+// `on` key is either an event, a list of events, or a mapping of events to their filters. The workflow is given by its
+// parsed content
+func ExtractTriggers(root *yaml.Node) []*model.Trigger {
+	triggers := []*model.Trigger{}
+	onNode := mappingValue(documentContent(root), "on")
+
+	if onNode == nil {
+		return triggers
+	}
+
+	if onNode.Kind != yaml.MappingNode {
+		for _, event := range scalarList(onNode) {
+			trigger := model.Trigger{}
+			trigger.Init(event, []string{}, []string{}, []string{}, []string{}, []string{}, []string{})
+
+			triggers = append(triggers, &trigger)
+		}
+
+		return triggers
+	}
+
+	for i := 0; i+1 < len(onNode.Content); i += 2 {
+		event := onNode.Content[i].Value
+		filters := onNode.Content[i+1]
+
+		schedules := []string{}
+
+		// Schedules are a list of mappings with a cron expression each
+		if event == "schedule" && filters.Kind == yaml.SequenceNode {
+			for _, schedule := range filters.Content {
+				if cron := mappingValue(schedule, "cron"); cron != nil {
+					schedules = append(schedules, scalar(cron))
+				}
+			}
+		}
+
+		inputs := []string{}
+
+		if inputsNode := mappingValue(filters, "inputs"); inputsNode != nil && inputsNode.Kind == yaml.MappingNode {
+			for j := 0; j < len(inputsNode.Content); j += 2 {
+				inputs = append(inputs, inputsNode.Content[j].Value)
+			}
+		}
+
+		trigger := model.Trigger{}
+		trigger.Init(
+			event,
+			scalarList(mappingValue(filters, "types")),
+			append(
+				append(scalarList(mappingValue(filters, "branches")), scalarList(mappingValue(filters, "tags"))...),
+				ignored(filters, "branches-ignore", "tags-ignore")...,
+			),
+			append(scalarList(mappingValue(filters, "paths")), ignored(filters, "paths-ignore")...),
+			schedules,
+			scalarList(mappingValue(filters, "workflows")),
+			inputs,
+		)
+
+		triggers = append(triggers, &trigger)
+	}
+
+	return triggers
+}
+
+// ignored returns the values of the given ignore filters of an event, prefixed with `!`
+func ignored(filters *yaml.Node, keys ...string) []string {
+	values := []string{}
+
+	for _, key := range keys {
+		for _, value := range scalarList(mappingValue(filters, key)) {
+			values = append(values, "!"+value)
+		}
+	}
+
+	return values
+}
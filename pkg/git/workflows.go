@@ -1,8 +1,12 @@
 package git
 
 import (
+	"kleio/pkg/config"
+	"kleio/pkg/docker"
 	"kleio/pkg/git/model"
+	"errors"
 	"fmt"
+	"io"
 	"maps"
 	"os"
 	"os/exec"
@@ -15,6 +19,9 @@ import (
 	"gopkg.in/yaml.v3"
 )
 
+// ErrNoWorkflows is returned when a repository never contained any workflow
+var ErrNoWorkflows = errors.New("no workflows found")
+
 // DeleteRepo deletes a repository directory
 func DeleteRepo(path string) {
 	err := os.RemoveAll(path)
@@ -35,9 +42,15 @@ func buildComponent(cType string, sep string, component string, components map[s
 		componentVersion = componentSplit[1]
 	}
 
+	// The names of Docker components are their whole reference, while their version is the digest or tag
 	if cType == "docker" {
 		componentName = "docker://" + component
-		provider = strings.Split(componentName, "/")[0]
+		componentVersion = ""
+
+		if image, err := docker.ParseImage(component); err == nil {
+			componentVersion = image.Version()
+			provider = image.Registry
+		}
 	}
 
 	if _, ok := components[componentName]; !ok {
@@ -57,10 +70,19 @@ func buildComponent(cType string, sep string, component string, components map[s
 	}
 }
 
-// extractComponents returns a slice of [Component] structs extracted from a workflow
-func extractComponents(content string) ([]*model.Component, error) {
+// ExtractComponents returns a slice of [Component] structs extracted from a workflow
+func ExtractComponents(content string) ([]*model.Component, error) {
 	var yamlStruct yaml.Node
 
+	if err := yaml.Unmarshal([]byte(content), &yamlStruct); err != nil {
+		return nil, nil
+	}
+
+	return extractComponents(&yamlStruct)
+}
+
+// extractComponents returns a slice of [Component] structs extracted from a workflow, given its parsed content
+func extractComponents(root *yaml.Node) ([]*model.Component, error) {
 	components := make(map[string]*model.Component)
 	actionDockerPath, err := yamlpath.NewPath("$..uses")
 
@@ -68,11 +90,7 @@ func extractComponents(content string) ([]*model.Component, error) {
 		return nil, err
 	}
 
-	if err = yaml.Unmarshal([]byte(content), &yamlStruct); err != nil {
-		return nil, nil
-	}
-
-	actionOut, err := actionDockerPath.Find(&yamlStruct)
+	actionOut, err := actionDockerPath.Find(root)
 
 	if err != nil {
 		return []*model.Component{}, nil
@@ -91,80 +109,83 @@ func extractComponents(content string) ([]*model.Component, error) {
 	return slices.Collect(maps.Values(components)), nil
 }
 
-// ExtractWorkflows returns a slice of [File] structs with their histories given the URL of a GitHub repository
-func ExtractWorkflows(url string) ([]model.File, error) {
-	var workflows []model.File
-
+// CloneRepository clones a GitHub repository given its URL (if not already in the filesystem) and returns its path.
+// URLs not starting with http are looked up in the REPOS_DIR directory instead. Progress messages are written to out
+func CloneRepository(url string, cfg *config.Config, out io.Writer) (string, error) {
 	_, filename, _, _ := runtime.Caller(0)
 
 	urlSplit := strings.Split(url, "/")
-	repoVendor := urlSplit[len(urlSplit)-2]
 	repoName := urlSplit[len(urlSplit)-1]
 	reposPath := path.Join(path.Dir(filename), "../../tmp/repos")
 	repoPath := path.Join(reposPath, repoName)
 
 	if !strings.HasPrefix(url, "http") {
-		customPath := os.Getenv("REPOS_DIR")
+		customPath := cfg.ReposDir
 
 		reposPath = path.Join(path.Dir(filename), "../..", customPath)
 		repoPath = path.Join(reposPath, repoName)
 
 		if _, err := os.Stat(path.Join(repoPath)); err != nil {
-			if os.IsNotExist(err) {
-				panic(err)
-			}
+			return "", err
 		}
 	} else {
+		// Repositories are cloned in a directory per vendor, since several of them might be cloned concurrently
+		reposPath = path.Join(reposPath, urlSplit[len(urlSplit)-2])
+		repoPath = path.Join(reposPath, repoName)
+
 		err := os.MkdirAll(reposPath, 0755)
 
 		if err != nil {
-			return nil, err
+			return "", err
 		}
 
 		// Clone the repo if it's not already in the filesystem
 		if _, err = os.Stat(path.Join(repoPath)); err != nil {
 			if os.IsNotExist(err) {
-				fmt.Print("Repo \033[31m" + repoName + "\033[0m not in filesystem, cloning (might take some time)")
+				fmt.Fprint(out, "Repo \033[31m" + repoName + "\033[0m not in filesystem, cloning (might take some time)")
 
 				cmd := exec.Command("git", "clone", url, repoPath)
 				err = cmd.Run()
 
 				if err != nil {
-					fmt.Println(" \u001B[31m𐄂\u001B[0m")
-					return nil, err
+					fmt.Fprintln(out, " \u001B[31m𐄂\u001B[0m")
+					return "", err
 				}
 
-				fmt.Println(" \u001B[32m✓\u001B[0m")
+				fmt.Fprintln(out, " \u001B[32m✓\u001B[0m")
 			}
 		}
 	}
 
-	fmt.Print("Extracting workflows from \033[31m" + repoName + "\033[0m and reading histories\n")
+	return repoPath, nil
+}
 
-	_, err := os.Stat(path.Join(repoPath, ".github/workflows"))
+// ExtractWorkflows returns a slice of [File] structs with their histories given the path of a cloned repository. If
+// saved contains the hashes of the commits already saved for a workflow, only the other commits are part of its
+// history. Progress messages are written to out
+func ExtractWorkflows(repoPath string, saved map[string]map[string]bool, out io.Writer) ([]model.File, error) {
+	var workflows []model.File
 
-	if err != nil {
-		return nil, err
-	}
+	fmt.Fprint(out, "Extracting workflows from \033[31m" + path.Base(repoPath) + "\033[0m and reading histories\n")
 
-	files, err := os.ReadDir(path.Join(repoPath, ".github/workflows"))
+	// Every file that ever existed in the `.github/workflows/` directory, followed across renames
+	lineages, err := getLineages(repoPath, ".github/workflows/")
 
 	if err != nil {
 		return nil, err
 	}
 
-	token := os.Getenv("GITHUB_PAT")
+	if len(lineages) == 0 {
+		return nil, ErrNoWorkflows
+	}
 
-	// For each workflow file in the `.github/workflows/` directory, extract its history
-	for _, f := range files {
-		if history, err := getFileHistory(repoPath, ".github/workflows/"+f.Name(), fmt.Sprintf("%s/%s", repoVendor, repoName), token); err == nil {
+	for _, l := range lineages {
+		if history, err := getFileHistory(repoPath, l, saved[l.name], out); err == nil {
 			workflows = append(workflows, history)
 		} else {
 			return nil, err
 		}
 	}
 
-	DeleteRepo(repoPath)
-
 	return workflows, nil
 }
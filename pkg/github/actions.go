@@ -2,34 +2,32 @@ package github
 
 import (
 	"kleio/cmd/database"
+	"kleio/pkg/config"
 	"kleio/pkg/git"
 	"kleio/pkg/git/model"
-	"bytes"
+	"kleio/pkg/lockfile"
+	"kleio/pkg/osv"
+	"kleio/pkg/vulns"
 	"context"
 	"encoding/json"
 	"fmt"
-	"math"
-	"net/http"
+	"maps"
 	"os"
 	"os/exec"
 	"path"
-	"regexp"
 	"runtime"
 	"slices"
 	"strings"
 	"time"
 
-	"github.com/aegis-forge/cage"
 	"github.com/gosuri/uilive"
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
-	gocvss20 "github.com/pandatix/go-cvss/20"
-	gocvss31 "github.com/pandatix/go-cvss/31"
-	gocvss40 "github.com/pandatix/go-cvss/40"
 )
 
-// A Release containing its tag as returned by the GitHub API
+// A Release containing its tag and publication date as returned by the GitHub API
 type release struct {
-	Tag string `json:"tag_name"`
+	Tag       string    `json:"tag_name"`
+	Published time.Time `json:"published_at"`
 }
 
 // A Tag with its SHA code as returned by the GitHub API
@@ -39,27 +37,9 @@ type tag struct {
 	} `json:"object"`
 }
 
-// A Lock is the structure of a package-lock.json file
-type lock struct {
-	Packages map[string]struct {
-		Version string `json:"version"`
-	} `json:"packages"`
-	Dependencies map[string]struct {
-		Version string `json:"version"`
-	} `json:"dependencies"`
-}
-
-type yarnLock struct {
-	Data struct {
-		Trees []struct {
-			Name string `json:"name"`
-		} `json:"trees"`
-	} `json:"data"`
-}
-
-// getTags returns all the version tags present in an Action's repository
-func getTags(action string, bearer string) ([]string, error) {
-	var releases []string
+// getTags returns all the releases present in an Action's repository
+func getTags(action string, client *Client, ctx context.Context) ([]release, error) {
+	var releases []release
 
 	writer := uilive.New()
 	writer.Start()
@@ -68,14 +48,9 @@ func getTags(action string, bearer string) ([]string, error) {
 
 	for {
 		uri := fmt.Sprintf("repos/%s/releases?page=%d&per_page=100", action, i)
-		res, _, err := PerformApiCall(uri, bearer, nil)
-
-		if err != nil {
-			return nil, err
-		}
 
 		var releasesRaw []release
-		err = json.NewDecoder(res).Decode(&releasesRaw)
+		err := client.GetJSON(ctx, uri, &releasesRaw)
 
 		if err != nil {
 			return nil, err
@@ -86,7 +61,7 @@ func getTags(action string, bearer string) ([]string, error) {
 		}
 
 		for index, release := range releasesRaw {
-			releases = append(releases, release.Tag)
+			releases = append(releases, release)
 
 			_, _ = fmt.Fprintf(
 				writer,
@@ -112,49 +87,40 @@ func getTags(action string, bearer string) ([]string, error) {
 }
 
 // getCommitHashes returns all the commit hashes connected to the version tags of an Action
-func getCommitHashes(action string, tags []string, bearer string) (map[string]string, error) {
+func getCommitHashes(action string, releases []release, client *Client, ctx context.Context) (map[string]string, error) {
 	hashes := map[string]string{}
 
 	writer := uilive.New()
 	writer.Start()
 
-	for index, tagz := range tags {
+	for index, release := range releases {
+		tagz := release.Tag
+
 		// Get tag SHA
 		uri := fmt.Sprintf("repos/%s/git/ref/tags/%s", action, tagz)
-		res, _, err := PerformApiCall(uri, bearer, nil)
-
-		if err != nil {
-			return nil, err
-		}
 
 		var tagRaw tag
-		err = json.NewDecoder(res).Decode(&tagRaw)
 
-		if err != nil {
-			return nil, err
+		// Releases whose tag does not exist anymore are skipped
+		if err := client.GetJSON(ctx, uri, &tagRaw); err != nil {
+			continue
 		}
 
-		// Get commit SHA
+		// Get commit SHA (lightweight tags point directly to the commit)
 		uri = fmt.Sprintf("repos/%s/git/tags/%s", action, tagRaw.Object.Sha)
-		res, status, err := PerformApiCall(uri, bearer, nil)
 
-		if err != nil || status != 200 {
+		var commitRaw tag
+
+		if err := client.GetJSON(ctx, uri, &commitRaw); err != nil {
 			hashes[tagz] = tagRaw.Object.Sha
 		} else {
-			var commitRaw tag
-			err = json.NewDecoder(res).Decode(&commitRaw)
-
-			if err != nil {
-				return nil, err
-			}
-
 			hashes[tagz] = commitRaw.Object.Sha
 		}
 
 		_, _ = fmt.Fprintf(
 			writer,
 			"[ACTIONS] Extracting tags [%d/%d]\n",
-			index+1, len(tags),
+			index+1, len(releases),
 		)
 	}
 
@@ -169,14 +135,77 @@ func getCommitHashes(action string, tags []string, bearer string) (map[string]st
 	return hashes, nil
 }
 
-// pullActionRepo clones an Action repo from GitHub and returns its absolute path
-func pullActionRepo(action string) (string, error) {
+// getTagHashes returns the commit hashes of the release tags of an Action, together with the publication dates of
+// the releases, retrieved from the source selected in the configuration. The REST API is used as a fallback if the
+// GraphQL API fails. The local source returns all the tags of the cloned Action repository instead, whether they have a
+// release or not, and no publication date, together with the heads of its branches (kept apart, since they are not
+// releases and move with every commit)
+func getTagHashes(action string, repoPath string, cfg *config.Config, client *Client, ctx context.Context) (map[string]string, map[string]string, map[string]time.Time, error) {
+	if cfg.GitHub.TagSource == config.TagSourceLocal {
+		tags, branches, err := git.GetRefs(repoPath)
+
+		if err != nil {
+			return nil, nil, nil, err
+		}
+
+		fmt.Printf(
+			"[ACTIONS] Extracting tags and branches from \033[31m%s\033[0m Action \u001B[32m✓\u001B[0m (%d tags, %d branches)\n",
+			action, len(tags), len(branches),
+		)
+
+		return tags, branches, map[string]time.Time{}, nil
+	}
+
+	if cfg.GitHub.TagSource == config.TagSourceGraphQL {
+		hashes, published, err := getTagsGraphQL(action, client, ctx)
+
+		if err == nil {
+			return hashes, map[string]string{}, published, nil
+		}
+
+		fmt.Printf("[ACTIONS] GraphQL API failed (%s), falling back to the REST API\n", err)
+	}
+
+	releases, err := getTags(action, client, ctx)
+
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	published := map[string]time.Time{}
+
+	for _, release := range releases {
+		if !release.Published.IsZero() {
+			published[release.Tag] = release.Published
+		}
+	}
+
+	hashes, err := getCommitHashes(action, releases, client, ctx)
+
+	return hashes, map[string]string{}, published, err
+}
+
+// splitAction returns the repository (`owner/repo`) of an Action and the directory of the Action in it, which is `.`
+// for the Actions at the root of their repository (e.g., `github/codeql-action/init` is in the `init` directory of
+// `github/codeql-action`)
+func splitAction(action string) (string, string) {
+	actionSplit := strings.SplitN(action, "/", 3)
+
+	if len(actionSplit) < 3 {
+		return action, "."
+	}
+
+	return actionSplit[0] + "/" + actionSplit[1], path.Clean(actionSplit[2])
+}
+
+// pullActionRepo clones the repo of an Action from GitHub and returns its absolute path
+func pullActionRepo(repo string) (string, error) {
 	_, filename, _, _ := runtime.Caller(0)
 
 	reposPath := path.Join(path.Dir(filename), "../../tmp/actions")
-	repoName := strings.Split(action, "/")[1]
+	repoName := strings.Split(repo, "/")[1]
 	repoPath := path.Join(reposPath, repoName)
-	repoUrl := fmt.Sprintf("https://github.com/%s", action)
+	repoUrl := fmt.Sprintf("https://github.com/%s", repo)
 
 	err := os.MkdirAll(reposPath, 0755)
 
@@ -204,12 +233,15 @@ func pullActionRepo(action string) (string, error) {
 	return repoPath, nil
 }
 
-// getActionVersions saves all the commits, versions, components, and vendors retrieved in the Neo4j database. It returns true if it saved at least one release
-func getActionVersions(action string, hashes map[string]string, repoPath string, driver neo4j.DriverWithContext, ctx context.Context) bool {
+// getActionVersions saves all the commits, versions, components, and vendors retrieved in the Neo4j database. The heads
+// of the branches are saved as versions too, but are not releases. It returns true if it saved at least one version
+func getActionVersions(action string, hashes map[string]string, branches map[string]string, repoPath string, cfg *config.Config, sources vulns.Sources, driver neo4j.DriverWithContext, ctx context.Context) bool {
 	versionToCommitMap := map[string][]string{}
-	actionSplit := strings.Split(action, "/")
+	actionSplit := strings.SplitN(action, "/", 2)
 
 	for tag, hash := range hashes {
+		found := false
+
 		for _, command := range []string{"tag", "branch"} {
 			cmd := exec.Command("git", "-C", repoPath, command, "--contains", hash)
 			out, err := cmd.Output()
@@ -228,10 +260,23 @@ func getActionVersions(action string, hashes map[string]string, repoPath string,
 				versionToCommitMap[version] = append(versionToCommitMap[version], hash)
 
 				if version == tag {
+					found = true
 					break
 				}
 			}
 		}
+
+		// Refs not listed by `git tag` or `git branch` still point to their own commit
+		if !found && !slices.Contains(versionToCommitMap[tag], hash) {
+			versionToCommitMap[tag] = append(versionToCommitMap[tag], hash)
+		}
+	}
+
+	// Branches (e.g., the remote branches of the clone) also push their head commit
+	for branch, hash := range branches {
+		if !slices.Contains(versionToCommitMap[branch], hash) {
+			versionToCommitMap[branch] = append(versionToCommitMap[branch], hash)
+		}
 	}
 
 	writer := uilive.New()
@@ -244,25 +289,17 @@ func getActionVersions(action string, hashes map[string]string, repoPath string,
 	for version, hashes := range versionToCommitMap {
 		i++
 
-		subtype := "composite"
-
-		if _, err := os.Stat(fmt.Sprintf("%s/package-lock.json", repoPath)); err == nil {
-			subtype = "js+lock"
-		} else if _, err := os.Stat(fmt.Sprintf("%s/yarn.lock", repoPath)); err == nil {
-			subtype = "js+lock"
-		} else if _, err := os.Stat(fmt.Sprintf("%s/package.json", repoPath)); err == nil {
-			subtype = "js"
-		}
-
 		_, _ = fmt.Fprintf(
 			writer,
 			"[ACTIONS] Saving releases [%d/%d]\n",
 			i, len(versionToCommitMap),
 		)
 
+		// The subtype of the Action depends on the commit, and is set from the manifests of its commits
 		database.ExecuteQueryNeo(
 			`MERGE (v:Vendor {name: $vendor})
-			MERGE (c:Component {full_name: $component, name: $action, type: "action", subtype: $subtype, provider: "github"})
+			MERGE (c:Component {full_name: $component})
+			ON CREATE SET c.name = $action, c.type = "action", c.provider = "github"
 			MERGE (ve:Version {full_name: $version, name: $semver})
 			MERGE (v)-[:PUBLISHES]->(c)
 			MERGE (c)-[:DEPLOYS]->(ve)`,
@@ -271,7 +308,6 @@ func getActionVersions(action string, hashes map[string]string, repoPath string,
 				"component": action,
 				"action":    actionSplit[1],
 				"version":   action + "/" + version,
-				"subtype":   subtype,
 				"semver":    version,
 			},
 			driver, ctx,
@@ -338,51 +374,23 @@ func getActionVersions(action string, hashes map[string]string, repoPath string,
 				driver, ctx,
 			)
 
-			if vulns, err := getActionVulnerabilities(actionSplit[0], actionSplit[1], version, date); err == nil {
-				for _, vuln := range vulns {
-					ratio := math.Pow(10, 2)
-					cvss := math.Round(float64(vuln.Cvss)*ratio) / ratio
-
-					database.ExecuteQueryNeo(
-						`MATCH (c:Commit {full_name: $commit})
-						MERGE (v:Vulnerability {id: $id, cve: $cve, cwes: $cwes, cvss: $cvss, published: $published})
-						MERGE (c)-[:VULNERABLE_TO]->(v)`,
-						map[string]any{
-							"commit":    action + "/" + hash,
-							"id":        vuln.Id,
-							"cve":       vuln.Cve,
-							"cwes":      vuln.Cwes,
-							"cvss":      cvss,
-							"published": vuln.Published,
-						},
-						driver, ctx,
-					)
-				}
-			}
-
-			var lock []byte
-			var lockType string
+			vulnerabilities, err := sources.Lookup(osv.EcosystemActions, action, version)
 
-			if _, err := os.Stat(fmt.Sprintf("%s/package-lock.json", repoPath)); err == nil {
-				lockType = "npm"
-			} else if _, err := os.Stat(fmt.Sprintf("%s/yarn.lock", repoPath)); err == nil {
-				lockType = "yarn"
+			if err != nil {
+				_, _ = fmt.Fprintf(
+					writer.Bypass(),
+					"\u001B[37m[VULNS]\u001B[0m %s \u001B[31m𐄂\u001B[0m \u001B[34m(%v)\u001B[0m\n",
+					action+"/"+hash, err,
+				)
 			}
 
-			cmd = exec.Command("git", "-C", repoPath, "show", fmt.Sprintf("%s:package.json", hash))
-			pkgJson, err := cmd.Output()
+			database.AddVulnerabilities("Commit", action+"/"+hash, vulnerabilities, driver, ctx)
 
-			switch lockType {
-			case "npm":
-				cmd = exec.Command("git", "-C", repoPath, "show", fmt.Sprintf("%s:package-lock.json", hash))
-				lock, err = cmd.Output()
-			case "yarn":
-				cmd = exec.Command("git", "-C", repoPath, "show", fmt.Sprintf("%s:yarn.lock", hash))
-				lock, err = cmd.Output()
-			}
+			if packages, err := getPackages(repoPath, hash); err == nil {
+				cmd = exec.Command("git", "-C", repoPath, "show", fmt.Sprintf("%s:package.json", hash))
+				pkgJson, _ := cmd.Output()
 
-			if err == nil {
-				getTransitiveDependenciesAndVulnerabilities(pkgJson, lock, lockType, repoPath, action+"/"+hash, driver, ctx, &checkedDependencies)
+				getTransitiveDependenciesAndVulnerabilities(pkgJson, packages, action+"/"+hash, sources, driver, ctx, &checkedDependencies)
 			}
 		}
 	}
@@ -402,91 +410,24 @@ func getActionVersions(action string, hashes map[string]string, repoPath string,
 	return true
 }
 
-func getPackages(lockFile []byte, repoPath, lockType string) (map[string]string, error) {
-	dependencies := map[string]string{}
-
-	switch lockType {
-	case "npm":
-		var npmLock lock
-
-		if err := json.Unmarshal(lockFile, &npmLock); err != nil {
-			return nil, err
-		}
-
-		it := npmLock.Packages
-
-		if it == nil {
-			it = npmLock.Dependencies
-		}
-
-		for name, version := range it {
-			dependencies[name] = version.Version
-		}
-	case "yarn":
-		cmd := exec.Command("/bin/bash", "-c", fmt.Sprintf("cd %s && yarn info --name-only -R --json", repoPath))
-		out, err := cmd.CombinedOutput()
+// getPackages returns the packages installed by the lockfile of an Action at one of its commits. The lockfiles are
+// read from the commit itself, and parsed without the need of any package manager
+func getPackages(repoPath string, hash string) ([]lockfile.Package, error) {
+	for _, filename := range lockfile.Filenames {
+		cmd := exec.Command("git", "-C", repoPath, "show", fmt.Sprintf("%s:%s", hash, filename))
+		lock, err := cmd.Output()
 
 		if err != nil {
-			fmt.Print(string(out))
-			return nil, err
-		}
-
-		lines := strings.Split(string(out), "\n")
-
-		if len(lines) <= 2 || strings.HasPrefix(lines[0], "{\\\"") {
-			// Try with `yarn list` if `yarn info` fails to produce dependencies
-			lines = []string{}
-
-			cmdF := exec.Command("/bin/bash", "-c", fmt.Sprintf("cd %s && yarn list --ignore-scripts --depth=0 --json", repoPath))
-			out, err = cmdF.CombinedOutput()
-
-			if err != nil {
-				fmt.Print(string(out))
-				return nil, err
-			}
-
-			var yarnLock yarnLock
-			err = json.Unmarshal(out, &yarnLock)
-
-			if err != nil || len(yarnLock.Data.Trees) == 0 {
-				return nil, err
-			}
-
-			for _, line := range yarnLock.Data.Trees {
-				lines = append(lines, line.Name)
-			}
-
-			if len(lines) == 0 {
-				return nil, nil
-			}
+			continue
 		}
 
-		for _, dep := range lines {
-			if dep == "" {
-				continue
-			}
-
-			dep = strings.ReplaceAll(dep, "\"", "")
-
-			re := regexp.MustCompile(`^(\S+)@\D*(.+)$`)
-			matches := re.FindStringSubmatch(dep)
-
-			if len(matches) < 2 {
-				continue
-			}
-
-			if strings.ContainsRune(matches[1], ':') || matches[2] == "." {
-				continue
-			}
-
-			dependencies[matches[1]] = matches[2]
-		}
+		return lockfile.Parse(filename, lock)
 	}
 
-	return dependencies, nil
+	return nil, fmt.Errorf("no lockfile found")
 }
 
-func getTransitiveDependenciesAndVulnerabilities(pkg, lock []byte, lockType, repoPath, commit string,
+func getTransitiveDependenciesAndVulnerabilities(pkg []byte, dependencies []lockfile.Package, commit string, sources vulns.Sources,
 	driver neo4j.DriverWithContext, ctx context.Context, checkedDependencies *[]string) {
 
 	type PackageJson struct {
@@ -500,13 +441,7 @@ func getTransitiveDependenciesAndVulnerabilities(pkg, lock []byte, lockType, rep
 	var optDeps []string
 
 	var pkgJson PackageJson
-	err := json.Unmarshal(pkg, &pkgJson)
-
-	dependencies, err := getPackages(lock, repoPath, lockType)
-
-	if err != nil {
-		return
-	}
+	_ = json.Unmarshal(pkg, &pkgJson)
 
 	writer := uilive.New()
 	writer.Start()
@@ -526,10 +461,8 @@ func getTransitiveDependenciesAndVulnerabilities(pkg, lock []byte, lockType, rep
 		}
 	}
 
-	for name, version := range dependencies {
-		if name == "" {
-			continue
-		}
+	for _, dependency := range dependencies {
+		name, version := dependency.Name, dependency.Version
 
 		i++
 
@@ -606,211 +539,169 @@ func getTransitiveDependenciesAndVulnerabilities(pkg, lock []byte, lockType, rep
 				driver, ctx,
 			)
 
-			osv_url := "https://api.osv.dev/v1/query"
-			body := map[string]any{
-				"package": map[string]string{
-					"purl": fmt.Sprintf("pkg:npm/%s@%s", cleanedName, version),
-				},
-			}
-
-			jsonBody, err := json.Marshal(body)
+			vulnerabilities, err := sources.Lookup(osv.EcosystemNpm, cleanedName, version)
 
 			if err != nil {
-				continue
-			}
-
-			res, err := http.Post(osv_url, "application/json", bytes.NewBuffer(jsonBody))
-
-			if err != nil {
-				continue
-			}
-
-			defer res.Body.Close()
-
-			type osvVuln struct {
-				Vulns []struct {
-					Id        string   `json:"id"`
-					Aliases   []string `json:"aliases"`
-					Published string   `json:"published"`
-					Advisory  struct {
-						CWEs []string `json:"cwe_ids"`
-					} `json:"database_specific"`
-					Severity []struct {
-						Type  string `json:"type"`
-						Score string `json:"score"`
-					} `json:"severity"`
-				} `json:"vulns"`
+				_, _ = fmt.Fprintf(
+					writer.Bypass(),
+					"\u001B[37m[VULNS]\u001B[0m %s \u001B[31m𐄂\u001B[0m \u001B[34m(%v)\u001B[0m\n",
+					cleanedName+"/"+version, err,
+				)
 			}
 
-			var ovsVulns osvVuln
+			database.AddVulnerabilities("Version", cleanedName+"/"+version, vulnerabilities, driver, ctx)
 
-			json.NewDecoder(res.Body).Decode(&ovsVulns)
+			*checkedDependencies = append(*checkedDependencies, cleanedName+"/"+version)
+		}
+	}
 
-			for _, vuln := range ovsVulns.Vulns {
-				cve := ""
-				cvss := 0.0
+	_, _ = fmt.Fprintf(
+		writer.Bypass(),
+		"[ACTIONS] Saving transitive dependencies \u001B[32m✓\u001B[0m\n",
+	)
+}
 
-				if len(vuln.Aliases) > 0 {
-					cve = vuln.Aliases[0]
-				}
+// GetActionsCommits retrieves all the versions and commits of all the Actions present in the repositories' workflows
+func GetActionsCommits(repo model.Repository, force bool, cfg *config.Config, client *Client, ledger *database.Ledger, bindings *database.Bindings, sources vulns.Sources, driver neo4j.DriverWithContext, ctx context.Context) {
+	actions := []string{}
 
-				if len(vuln.Severity) > 0 {
-					vector := vuln.Severity[0].Score
-
-					switch vuln.Severity[0].Type {
-					case "CVSS_V3":
-						cvssObj, err := gocvss31.ParseVector(vector)
-
-						if err != nil {
-							cvss = 0.0
-						} else {
-							cvss = cvssObj.BaseScore()
-						}
-					case "CVSS_V4":
-						cvssObj, err := gocvss40.ParseVector(vector)
-
-						if err != nil {
-							cvss = 0.0
-						} else {
-							cvss = cvssObj.Score()
-						}
-					default:
-						cvssObj, err := gocvss20.ParseVector(vector)
-
-						if err != nil {
-							cvss = 0.0
-						} else {
-							cvss = cvssObj.BaseScore()
-						}
-					}
+	for _, workflow := range repo.GetFiles() {
+		for _, commit := range workflow.GetHistory() {
+			for _, component := range commit.GetComponents() {
+				if component.GetCategory() != "action" {
+					continue
 				}
 
-				database.ExecuteQueryNeo(
-					`MATCH (c:Version {full_name: $version})
-					MERGE (v:Vulnerability {id: $id, cve: $cve, cwes: $cwes, cvss: $cvss, published: $published})
-					MERGE (c)-[:VULNERABLE_TO]->(v)`,
-					map[string]any{
-						"version":   cleanedName + "/" + version,
-						"id":        vuln.Id,
-						"cve":       cve,
-						"cwes":      vuln.Advisory.CWEs,
-						"cvss":      cvss,
-						"published": vuln.Published,
-					},
-					driver, ctx,
-				)
+				actions = append(actions, component.GetName())
 			}
-
-			*checkedDependencies = append(*checkedDependencies, cleanedName+"/"+version)
 		}
 	}
 
-	_, _ = fmt.Fprintf(
-		writer.Bypass(),
-		"[ACTIONS] Saving transitive dependencies \u001B[32m✓\u001B[0m\n",
-	)
+	ResolveActions(actions, force, cfg, client, ledger, bindings, sources, driver, ctx)
 }
 
-func getActionVulnerabilities(vendor, action, version string, time time.Time) ([]cage.Vulnerability, error) {
-	semver, err := cage.NewSemver(version)
+// resolveAction retrieves and saves all the versions and commits of an Action, recording its progress in the ledger
+// and the commits its tags point to in the bindings. The versions of the Actions nested in a repository are the ones of
+// their repository, while their manifests are read from their directory. It returns the manifests of the commits of
+// the Action
+func resolveAction(action string, cfg *config.Config, client *Client, ledger *database.Ledger, bindings *database.Bindings, sources vulns.Sources, driver neo4j.DriverWithContext, ctx context.Context) (manifests []actionManifest, err error) {
+	repoPath := ""
+	repo, directory := splitAction(action)
+
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v", r)
+		}
+
+		if err != nil {
+			ledger.Fail(database.KindAction, action, err)
+		}
+
+		// Delete Action repository
+		if repoPath != "" {
+			git.DeleteRepo(repoPath)
+		}
+	}()
+
+	ledger.Mark(database.KindAction, action, database.StateQueued)
+
+	// Pull Action repo
+	repoPath, err = pullActionRepo(repo)
 
 	if err != nil {
 		return nil, err
 	}
 
-	pkg, err := cage.NewPackage(vendor, action, time, semver)
+	ledger.Mark(database.KindAction, action, database.StateCloned)
+
+	// Extract the release tags and their commit hashes
+	hashes, branches, published, err := getTagHashes(repo, repoPath, cfg, client, ctx)
 
 	if err != nil {
 		return nil, err
 	}
 
-	// token, err := helpers.ReadConfig()
-
-	// if err != nil {
-	// 	return nil, err
-	// }
+	ledger.Mark(database.KindAction, action, database.StateResolved)
 
-	gh := cage.Github{}
-	// gh.SetToken(token.Section("GITHUB").Key("TOKEN_VULNS").String())
-
-	if os.Getenv("GITHUB_PAT_VULN") != "" {
-		gh.SetToken(os.Getenv("GITHUB_PAT_VULN"))
-	} else {
-		gh.SetToken(os.Getenv("GITHUB_PAT"))
+	// Extract and save the versions of the Action
+	if found := getActionVersions(action, hashes, branches, repoPath, cfg, sources, driver, ctx); !found {
+		return nil, fmt.Errorf("no releases found")
 	}
 
-	return pkg.IsVulnerable([]cage.Source{gh})
-}
+	// Extract the runtime and the nested Actions and Docker images of each commit of the Action
+	manifests = getManifests(action, directory, hashes, branches, repoPath, driver, ctx)
 
-// GetActionsCommits retrieves all the versions and commits of all the Actions present in the repositories' workflows
-func GetActionsCommits(repo model.Repository, driver neo4j.DriverWithContext, ctx context.Context) {
-	bearer := os.Getenv("GITHUB_PAT")
-	errorActions := []string{}
+	// Fingerprint the bundles of the commits of JavaScript Actions
+	getBundles(manifests, repoPath, driver, ctx)
 
-	for _, workflow := range repo.GetFiles() {
-		for _, commit := range workflow.GetHistory() {
-			for _, component := range commit.GetComponents() {
-				if component.GetCategory() != "action" {
-					continue
-				}
+	// Detect the tags that were moved since the last crawl, or after their release was published. All the tags of the
+	// clone are bound, including the ones without a release (e.g., floating major tags such as `v3`)
+	tags := map[string]string{}
+	maps.Copy(tags, hashes)
 
-				action := component.GetName()
+	if cloned, _, err := git.GetRefs(repoPath); err == nil {
+		maps.Copy(tags, cloned)
+	}
 
-				if slices.Contains(errorActions, action) {
-					continue
-				}
+	bindings.Visit(action)
+	detectRetags(action, tags, published, repoPath, bindings, driver, ctx)
 
-				if len(strings.Split(action, "/")) > 2 {
-					actionSplit := strings.Split(action, "/")
-					action = strings.Join(actionSplit[:len(actionSplit)-1], "/")
-				}
+	ledger.Mark(database.KindAction, action, database.StatePersisted)
 
-				// Check if Action exists in database
-				if res := database.ExecuteQueryWithRetNeo(
-					`MATCH (c:Component {full_name: $component})
-					WITH COUNT(c) > 0 as node_c
-					RETURN node_c`,
-					map[string]any{
-						"component": action,
-					},
-					driver, ctx,
-				); res[0].Values[0] == true || strings.HasPrefix(action, "./") {
-					continue
-				}
-
-				// Extract the release tags
-				tags, err := getTags(action, bearer)
+	return manifests, nil
+}
 
-				if err != nil {
-					errorActions = append(errorActions, action)
-					continue
-				}
+// ResolveActions retrieves all the versions and commits of the given Actions. Actions already persisted (according to
+// the ledger, or to the database if the ledger does not know them) are not resolved again unless force is set, but the
+// tags moved since they were last observed are still detected. Actions that previously failed are retried. The Actions
+// used by composite Actions are resolved recursively
+func ResolveActions(actions []string, force bool, cfg *config.Config, client *Client, ledger *database.Ledger, bindings *database.Bindings, sources vulns.Sources, driver neo4j.DriverWithContext, ctx context.Context) {
+	resolvedActions := []string{}
 
-				// Extract the commit hashes from the release tags
-				hashes, err := getCommitHashes(action, tags, bearer)
+	resolveActions(actions, force, &resolvedActions, cfg, client, ledger, bindings, sources, driver, ctx)
+}
 
-				if err != nil {
-					errorActions = append(errorActions, action)
-					continue
-				}
+// resolveActions resolves the given Actions and, depth first, the Actions they use. Actions already in resolvedActions
+// are skipped, so that cycles between composite Actions terminate
+func resolveActions(actions []string, force bool, resolvedActions *[]string, cfg *config.Config, client *Client, ledger *database.Ledger, bindings *database.Bindings, sources vulns.Sources, driver neo4j.DriverWithContext, ctx context.Context) {
+	for _, action := range actions {
+		if strings.HasPrefix(action, "./") || slices.Contains(*resolvedActions, action) {
+			continue
+		}
 
-				// Pull Action repo
-				repoPath, err := pullActionRepo(action)
+		*resolvedActions = append(*resolvedActions, action)
 
-				if err != nil {
-					errorActions = append(errorActions, action)
-					git.DeleteRepo(repoPath)
+		if !force {
+			if checkpoint, found := ledger.Get(database.KindAction, action); found {
+				if checkpoint.State == database.StatePersisted {
+					refreshRetags(action, bindings, driver, ctx)
 					continue
 				}
+			} else if res := database.ExecuteQueryWithRetNeo(
+				`MATCH (c:Component {full_name: $component})
+				WITH COUNT(c) > 0 as node_c
+				RETURN node_c`,
+				map[string]any{
+					"component": action,
+				},
+				driver, ctx,
+			); res[0].Values[0] == true {
+				// Check if Action exists in database
+				refreshRetags(action, bindings, driver, ctx)
+				continue
+			}
+		}
 
-				// Extract and save the versions of the Action
-				if found := getActionVersions(action, hashes, repoPath, driver, ctx); !found {
-					errorActions = append(errorActions, action)
-				}
+		manifests, err := resolveAction(action, cfg, client, ledger, bindings, sources, driver, ctx)
 
-				// Delete Action repository
-				git.DeleteRepo(repoPath)
-			}
+		if err != nil {
+			fmt.Printf("[ACTIONS] Resolving \033[31m%s\033[0m Action \u001B[31m𐄂\u001B[0m (%s)\n", action, err)
+
+			continue
 		}
+
+		// The used Actions must be saved before the commits of the Action can be connected to theirs
+		resolveActions(nestedActions(manifests), force, resolvedActions, cfg, client, ledger, bindings, sources, driver, ctx)
+		linkManifests(manifests, driver, ctx)
 	}
 }
@@ -0,0 +1,98 @@
+package github
+
+import (
+	"kleio/cmd/database"
+	"kleio/pkg/git"
+	"kleio/pkg/lockfile"
+	"context"
+	"path"
+	"slices"
+	"strings"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// sourceExtensions are the extensions of the files a bundle is built from
+var sourceExtensions = []string{".js", ".cjs", ".mjs", ".jsx", ".ts", ".cts", ".mts", ".tsx", ".json"}
+
+// getBundles saves the fingerprint of the bundle (the directory of `runs.main`) of the commits of a JavaScript
+// Action. Each commit is compared with the most recent earlier commit on its ancestry line (so that the tags of
+// different release branches are not compared with each other), and the commits (and their versions) whose bundle
+// changed while neither the sources nor the lockfiles did are marked with `dist_only_change`, since the shipped code
+// changed in a way that cannot be reviewed
+func getBundles(manifests []actionManifest, repoPath string, driver neo4j.DriverWithContext, ctx context.Context) {
+	type fingerprint struct {
+		hash   string
+		bundle string
+	}
+
+	previous := []fingerprint{}
+
+	slices.SortFunc(manifests, func(a, b actionManifest) int {
+		return a.date.Compare(b.date)
+	})
+
+	for _, manifest := range manifests {
+		if manifest.manifest.GetSubtype() != "javascript" {
+			continue
+		}
+
+		// The entrypoint is relative to the manifest, which is not at the root of the repository for nested Actions
+		bundle, err := git.GetBundle(repoPath, manifest.hash, path.Join(manifest.directory, manifest.manifest.GetMain()))
+
+		if err != nil {
+			continue
+		}
+
+		distOnly := false
+
+		for i := len(previous) - 1; i >= 0; i-- {
+			if !git.IsAncestor(repoPath, previous[i].hash, manifest.hash) {
+				continue
+			}
+
+			if previous[i].bundle != bundle.GetHash() {
+				if files, err := git.GetChangedFiles(repoPath, previous[i].hash, manifest.hash); err == nil {
+					distOnly = !slices.ContainsFunc(files, func(file string) bool {
+						return isSource(file, bundle.GetPath())
+					})
+				}
+			}
+
+			break
+		}
+
+		// Versions are recomputed from all their commits, so that they are reset when none is a dist-only change anymore
+		database.ExecuteQueryNeo(
+			`MATCH (c:Commit {full_name: $commit})
+			SET c.dist_path = $path, c.dist_hash = $hash, c.dist_size = $size, c.dist_modules = $modules,
+				c.dist_only_change = $dist_only
+			WITH c
+			MATCH (v:Version)-[:PUSHES]->(c)
+			OPTIONAL MATCH (v)-[:PUSHES]->(o:Commit {dist_only_change: true})
+			WITH v, COUNT(o) > 0 AS dist_only
+			SET v.dist_only_change = dist_only`,
+			map[string]any{
+				"commit":    manifest.commit,
+				"path":      bundle.GetPath(),
+				"hash":      bundle.GetHash(),
+				"size":      bundle.GetSize(),
+				"modules":   bundle.GetModules(),
+				"dist_only": distOnly,
+			},
+			driver, ctx,
+		)
+
+		previous = append(previous, fingerprint{hash: manifest.hash, bundle: bundle.GetHash()})
+	}
+}
+
+// isSource returns whether a file (outside the bundle directory) is one of the sources or lockfiles a bundle is built
+// from
+func isSource(file string, bundle string) bool {
+	if file == bundle || strings.HasPrefix(file, bundle+"/") {
+		return false
+	}
+
+	return slices.Contains(lockfile.Filenames, path.Base(file)) || slices.Contains(sourceExtensions, path.Ext(file))
+}
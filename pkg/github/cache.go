@@ -0,0 +1,76 @@
+package github
+
+import (
+	"container/list"
+	"sync"
+)
+
+// maxCacheSize is the maximum total size (in bytes) of the bodies of the responses kept by a [responseCache]
+const maxCacheSize = 64 << 20
+
+// A cachedResponse is a response of the GitHub API saved together with its ETag
+type cachedResponse struct {
+	url  string
+	etag string
+	body []byte
+}
+
+// A responseCache keeps the most recently used responses of the GitHub API, evicting the least recently used ones
+// once the total size of their bodies exceeds its capacity
+type responseCache struct {
+	mutex    sync.Mutex
+	capacity int
+	size     int
+	order    *list.List
+	entries  map[string]*list.Element
+}
+
+// newResponseCache returns an empty [responseCache] keeping at most capacity bytes of bodies
+func newResponseCache(capacity int) *responseCache {
+	return &responseCache{capacity: capacity, order: list.New(), entries: map[string]*list.Element{}}
+}
+
+// get returns the response cached for a URL, marking it as the most recently used
+func (c *responseCache) get(url string) (cachedResponse, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	element, ok := c.entries[url]
+
+	if !ok {
+		return cachedResponse{}, false
+	}
+
+	c.order.MoveToFront(element)
+
+	return element.Value.(cachedResponse), true
+}
+
+// put caches the response of a URL, replacing the previous one. Bodies larger than the capacity are not cached
+func (c *responseCache) put(url string, etag string, body []byte) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if element, ok := c.entries[url]; ok {
+		c.remove(element)
+	}
+
+	if len(body) > c.capacity {
+		return
+	}
+
+	c.entries[url] = c.order.PushFront(cachedResponse{url: url, etag: etag, body: body})
+	c.size += len(body)
+
+	for c.size > c.capacity {
+		c.remove(c.order.Back())
+	}
+}
+
+// remove evicts an entry of the [responseCache]. The caller must hold the mutex
+func (c *responseCache) remove(element *list.Element) {
+	response := c.order.Remove(element).(cachedResponse)
+	c.size -= len(response.body)
+
+	delete(c.entries, response.url)
+}
@@ -0,0 +1,253 @@
+package github
+
+import (
+	"kleio/pkg/config"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRateLimitWaits is the number of times a call is retried after being rate limited, before giving up
+const maxRateLimitWaits = 10
+
+// A Client performs calls to the GitHub API. Each call uses the token of the pool with the most remaining quota, and
+// the client waits for the rate limit to reset only when all the tokens are exhausted. Calls failing with a server
+// error are retried, and the most recent responses are cached by their ETag, so that repeated calls do not consume
+// rate limit
+type Client struct {
+	baseURL    string
+	graphqlURL string
+	tokens     *TokenPool
+	retries    int
+	http       *http.Client
+	cache      *responseCache
+}
+
+// NewClient returns a [Client] configured with the given GitHub options
+func NewClient(cfg config.GitHub) *Client {
+	baseURL := strings.TrimSuffix(cfg.APIURL, "/") + "/"
+	graphqlURL := baseURL + "graphql"
+
+	// GitHub Enterprise serves the REST API under /api/v3/ and the GraphQL API under /api/graphql
+	if strings.HasSuffix(baseURL, "/api/v3/") {
+		graphqlURL = strings.TrimSuffix(baseURL, "v3/") + "graphql"
+	}
+
+	return &Client{
+		baseURL:    baseURL,
+		graphqlURL: graphqlURL,
+		tokens:     NewTokenPool(cfg.GetTokens()),
+		retries:    cfg.Retries,
+		http:       &http.Client{Timeout: time.Minute},
+		cache:      newResponseCache(maxCacheSize),
+	}
+}
+
+// Get performs a GET call to the given URI (relative to the base URL of the API), and returns the body and status
+// code of the response. The body must be closed by the caller
+func (c *Client) Get(ctx context.Context, uri string) (io.ReadCloser, int, error) {
+	uri = strings.TrimPrefix(uri, "/")
+	body, status, err := c.do(ctx, http.MethodGet, c.baseURL+uri, resourceOf(uri), nil)
+
+	if err != nil {
+		return nil, -1, err
+	}
+
+	return io.NopCloser(bytes.NewReader(body)), status, nil
+}
+
+// GetJSON performs a GET call to the given URI and decodes the JSON body of the response in out. An error is returned
+// if the status code of the response is not 200
+func (c *Client) GetJSON(ctx context.Context, uri string, out any) error {
+	uri = strings.TrimPrefix(uri, "/")
+	body, status, err := c.do(ctx, http.MethodGet, c.baseURL+uri, resourceOf(uri), nil)
+
+	if err != nil {
+		return err
+	}
+
+	if status != http.StatusOK {
+		return fmt.Errorf("%s: unexpected status code %d", uri, status)
+	}
+
+	return json.Unmarshal(body, out)
+}
+
+// GraphQL performs a call to the GraphQL API with the given query and variables, and decodes the `data` field of the
+// response in out. An error is returned if the response contains any error
+func (c *Client) GraphQL(ctx context.Context, query string, variables map[string]any, out any) error {
+	payload, err := json.Marshal(map[string]any{"query": query, "variables": variables})
+
+	if err != nil {
+		return err
+	}
+
+	body, status, err := c.do(ctx, http.MethodPost, c.graphqlURL, "graphql", payload)
+
+	if err != nil {
+		return err
+	}
+
+	if status != http.StatusOK {
+		return fmt.Errorf("graphql: unexpected status code %d", status)
+	}
+
+	var res struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+
+	if err = json.Unmarshal(body, &res); err != nil {
+		return err
+	}
+
+	if len(res.Errors) > 0 {
+		return fmt.Errorf("graphql: %s", res.Errors[0].Message)
+	}
+
+	return json.Unmarshal(res.Data, out)
+}
+
+// do performs a call to the given URL of the GitHub API (whose rate limit is counted towards resource), handling rate
+// limits, retries, and conditional requests
+func (c *Client) do(ctx context.Context, method, url, resource string, payload []byte) ([]byte, int, error) {
+	cached, isCached := c.cache.get(url)
+	waits := 0
+
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(payload))
+
+		if err != nil {
+			return nil, -1, err
+		}
+
+		token, wait := c.tokens.Next(resource)
+
+		if wait > 0 {
+			fmt.Printf("\u001B[37m[GITHUB]\u001B[0m Rate limit exceeded for all tokens, waiting %s\n", wait.Round(time.Second))
+
+			if err = sleep(ctx, wait); err != nil {
+				return nil, -1, err
+			}
+		}
+
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Accept", "application/vnd.github+json")
+		req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+
+		if payload != nil {
+			req.Header.Set("Content-Type", "application/json")
+		}
+
+		if method == http.MethodGet && isCached {
+			req.Header.Set("If-None-Match", cached.etag)
+		}
+
+		res, err := c.http.Do(req)
+
+		if err != nil {
+			if attempt < c.retries && ctx.Err() == nil {
+				if err = sleep(ctx, backoff(attempt)); err != nil {
+					return nil, -1, err
+				}
+
+				continue
+			}
+
+			return nil, -1, err
+		}
+
+		c.tokens.Update(token, res.Header)
+
+		body, err := io.ReadAll(res.Body)
+		res.Body.Close()
+
+		if err != nil {
+			return nil, -1, err
+		}
+
+		switch {
+		case res.StatusCode == http.StatusNotModified && isCached:
+			return cached.body, http.StatusOK, nil
+		case res.StatusCode == http.StatusForbidden || res.StatusCode == http.StatusTooManyRequests:
+			wait, limited := rateLimitWait(res)
+
+			if !limited {
+				return body, res.StatusCode, nil
+			}
+
+			if waits++; waits > maxRateLimitWaits {
+				return nil, -1, fmt.Errorf("%s: still rate limited after %d attempts", url, maxRateLimitWaits)
+			}
+
+			// The next attempt uses another token, or waits for the rate limit to reset if all of them are exhausted.
+			// Being rate limited does not count as a retry
+			c.tokens.Exhaust(token, resource, time.Now().Add(wait))
+			attempt--
+		case res.StatusCode >= http.StatusInternalServerError && attempt < c.retries:
+			if err = sleep(ctx, backoff(attempt)); err != nil {
+				return nil, -1, err
+			}
+		default:
+			if etag := res.Header.Get("ETag"); method == http.MethodGet && res.StatusCode == http.StatusOK && etag != "" {
+				c.cache.put(url, etag, body)
+			}
+
+			return body, res.StatusCode, nil
+		}
+	}
+}
+
+// rateLimitWait returns how long to wait before retrying a call that was rate limited (either by the primary or the
+// secondary rate limit, as told by the headers of the response), and whether the call was rate limited at all
+func rateLimitWait(res *http.Response) (time.Duration, bool) {
+	if retryAfter := res.Header.Get("Retry-After"); retryAfter != "" {
+		if seconds, err := strconv.Atoi(retryAfter); err == nil {
+			return time.Duration(seconds) * time.Second, true
+		}
+	}
+
+	if res.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(res.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return max(time.Until(time.Unix(reset, 0))+time.Second, time.Second), true
+		}
+
+		return time.Minute, true
+	}
+
+	if res.StatusCode == http.StatusTooManyRequests {
+		// Secondary rate limits without any header ask to wait at least one minute
+		return time.Minute, true
+	}
+
+	return 0, false
+}
+
+// backoff returns the exponential backoff (with jitter) to wait before the given retry attempt
+func backoff(attempt int) time.Duration {
+	base := time.Second << min(attempt, 6)
+
+	return base/2 + time.Duration(rand.Int64N(int64(base)))
+}
+
+// sleep waits for the given duration, or until the context is done
+func sleep(ctx context.Context, duration time.Duration) error {
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
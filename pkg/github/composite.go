@@ -0,0 +1,107 @@
+package github
+
+import (
+	"kleio/cmd/database"
+	"kleio/pkg/git"
+	"kleio/pkg/git/model"
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// An actionManifest is the manifest of an Action at one of its commits (given by full name), read from the directory of
+// the Action in its repository
+type actionManifest struct {
+	commit    string
+	hash      string
+	date      time.Time
+	directory string
+	manifest  model.Manifest
+}
+
+// getManifests saves the runtime (`runs.using`), subtype, and Docker image of the commits of an Action (the ones of its
+// tags and the heads of its branches), and returns their manifests. Commits without a manifest are skipped. The Action
+// component takes the subtype of its most recent commit
+func getManifests(action string, directory string, hashes map[string]string, branches map[string]string, repoPath string, driver neo4j.DriverWithContext, ctx context.Context) []actionManifest {
+	manifests := []actionManifest{}
+	commits := slices.Concat(slices.Collect(maps.Values(hashes)), slices.Collect(maps.Values(branches)))
+
+	// Several tags and branches might point to the same commit
+	for _, hash := range slices.Compact(slices.Sorted(slices.Values(commits))) {
+		manifest, err := git.GetManifest(repoPath, directory, hash)
+
+		if err != nil {
+			continue
+		}
+
+		date, err := git.GetCommitDate(repoPath, hash)
+
+		if err != nil {
+			continue
+		}
+
+		database.ExecuteQueryNeo(
+			`MATCH (c:Commit {full_name: $commit})
+			SET c.using = $using, c.subtype = $subtype, c.image = $image`,
+			map[string]any{
+				"commit":  action + "/" + hash,
+				"using":   manifest.GetUsing(),
+				"subtype": manifest.GetSubtype(),
+				"image":   manifest.GetImage(),
+			},
+			driver, ctx,
+		)
+
+		manifests = append(manifests, actionManifest{
+			commit:    action + "/" + hash,
+			hash:      hash,
+			date:      date,
+			directory: directory,
+			manifest:  manifest,
+		})
+	}
+
+	if len(manifests) > 0 {
+		latest := slices.MaxFunc(manifests, func(a, b actionManifest) int {
+			return a.date.Compare(b.date)
+		})
+
+		database.ExecuteQueryNeo(
+			`MATCH (c:Component {full_name: $component})
+			SET c.subtype = $subtype`,
+			map[string]any{
+				"component": action,
+				"subtype":   latest.manifest.GetSubtype(),
+			},
+			driver, ctx,
+		)
+	}
+
+	return manifests
+}
+
+// nestedActions returns the Actions used by the steps of the given manifests of composite Actions
+func nestedActions(manifests []actionManifest) []string {
+	actions := []string{}
+
+	for _, manifest := range manifests {
+		for _, component := range manifest.manifest.GetComponents() {
+			if component.GetCategory() == "action" && !slices.Contains(actions, component.GetName()) {
+				actions = append(actions, component.GetName())
+			}
+		}
+	}
+
+	return actions
+}
+
+// linkManifests connects the commits of an Action to the commits of the Actions (and to the Docker images) used by
+// their manifests, so that transitive dependencies appear as chains of USES relationships
+func linkManifests(manifests []actionManifest, driver neo4j.DriverWithContext, ctx context.Context) {
+	for _, manifest := range manifests {
+		database.AddActionComponents(manifest.commit, manifest.date, manifest.manifest.GetComponents(), driver, ctx)
+	}
+}
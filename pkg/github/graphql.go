@@ -0,0 +1,146 @@
+package github
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// tagsQuery retrieves the tags of a repository, together with the commits they point to (peeling annotated tags)
+const tagsQuery = `query($owner: String!, $name: String!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    refs(refPrefix: "refs/tags/", first: 100, after: $cursor) {
+      pageInfo { hasNextPage endCursor }
+      nodes {
+        name
+        target {
+          oid
+          ... on Tag { target { oid ... on Tag { target { oid } } } }
+        }
+      }
+    }
+  }
+}`
+
+// releasesQuery retrieves the tags and publication dates of the releases of a repository
+const releasesQuery = `query($owner: String!, $name: String!, $cursor: String) {
+  repository(owner: $owner, name: $name) {
+    releases(first: 100, after: $cursor) {
+      pageInfo { hasNextPage endCursor }
+      nodes { tagName publishedAt }
+    }
+  }
+}`
+
+// A pageInfo is the pagination information of a GraphQL connection
+type pageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+// A gitObject is a git object as returned by the GraphQL API. Annotated tags contain the object they point to
+type gitObject struct {
+	Oid    string     `json:"oid"`
+	Target *gitObject `json:"target"`
+}
+
+// peel returns the OID of the commit a chain of annotated tags points to
+func (o *gitObject) peel() string {
+	for o.Target != nil {
+		o = o.Target
+	}
+
+	return o.Oid
+}
+
+// getTagsGraphQL returns the commit hashes of the release tags of an Action and the publication dates of the releases,
+// retrieving all the tags and releases with a handful of paginated GraphQL queries
+func getTagsGraphQL(action string, client *Client, ctx context.Context) (map[string]string, map[string]time.Time, error) {
+	actionSplit := strings.Split(action, "/")
+	variables := map[string]any{"owner": actionSplit[0], "name": actionSplit[1], "cursor": nil}
+
+	tags := map[string]string{}
+
+	for {
+		var res struct {
+			Repository *struct {
+				Refs struct {
+					PageInfo pageInfo `json:"pageInfo"`
+					Nodes    []struct {
+						Name   string    `json:"name"`
+						Target gitObject `json:"target"`
+					} `json:"nodes"`
+				} `json:"refs"`
+			} `json:"repository"`
+		}
+
+		if err := client.GraphQL(ctx, tagsQuery, variables, &res); err != nil {
+			return nil, nil, err
+		}
+
+		if res.Repository == nil {
+			return nil, nil, fmt.Errorf("repository %s not found", action)
+		}
+
+		for _, node := range res.Repository.Refs.Nodes {
+			tags[node.Name] = node.Target.peel()
+		}
+
+		if !res.Repository.Refs.PageInfo.HasNextPage {
+			break
+		}
+
+		variables["cursor"] = res.Repository.Refs.PageInfo.EndCursor
+	}
+
+	fmt.Printf("[ACTIONS] Extracting tags from \033[31m%s\033[0m Action \u001B[32m✓\u001B[0m (%d tags)\n", action, len(tags))
+
+	// Only the tags with a release are kept, as when retrieving them through the REST API
+	hashes := map[string]string{}
+	published := map[string]time.Time{}
+	variables["cursor"] = nil
+
+	for {
+		var res struct {
+			Repository *struct {
+				Releases struct {
+					PageInfo pageInfo `json:"pageInfo"`
+					Nodes    []struct {
+						TagName     string     `json:"tagName"`
+						PublishedAt *time.Time `json:"publishedAt"`
+					} `json:"nodes"`
+				} `json:"releases"`
+			} `json:"repository"`
+		}
+
+		if err := client.GraphQL(ctx, releasesQuery, variables, &res); err != nil {
+			return nil, nil, err
+		}
+
+		if res.Repository == nil {
+			return nil, nil, fmt.Errorf("repository %s not found", action)
+		}
+
+		for _, node := range res.Repository.Releases.Nodes {
+			if hash, ok := tags[node.TagName]; ok {
+				hashes[node.TagName] = hash
+
+				// Draft releases are not published
+				if node.PublishedAt != nil {
+					published[node.TagName] = *node.PublishedAt
+				}
+			}
+		}
+
+		if !res.Repository.Releases.PageInfo.HasNextPage {
+			break
+		}
+
+		variables["cursor"] = res.Repository.Releases.PageInfo.EndCursor
+	}
+
+	fmt.Printf("[ACTIONS] Extracting releases from \033[31m%s\033[0m Action \u001B[32m✓\u001B[0m (%d releases)\n", action, len(hashes))
+
+	return hashes, published, nil
+}
@@ -0,0 +1,116 @@
+package github
+
+import (
+	"kleio/cmd/database"
+	"kleio/pkg/git"
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+// refreshRetags detects the tags of an already resolved Action that were moved since it was last observed, without
+// resolving it again. The tags are listed with `git ls-remote`, so that neither a clone nor any API call is needed.
+// The tags of each Action are only observed once per crawl
+func refreshRetags(action string, bindings *database.Bindings, driver neo4j.DriverWithContext, ctx context.Context) {
+	if !bindings.Visit(action) {
+		return
+	}
+
+	repo, _ := splitAction(action)
+	tags, err := git.GetRemoteTags(fmt.Sprintf("https://github.com/%s", repo))
+
+	if err != nil {
+		fmt.Printf("[ACTIONS] Listing the tags of \033[31m%s\033[0m Action \u001B[31m𐄂\u001B[0m (%s)\n", action, err)
+
+		return
+	}
+
+	// Without a clone, the commit dates cannot be compared with the publication of the releases
+	detectRetags(action, tags, map[string]time.Time{}, "", bindings, driver, ctx)
+}
+
+// detectRetags records the commits the tags of an Action currently point to, and detects the tags that were moved
+// (e.g., in a rug-pull). A tag pointing to a different commit than when it was last observed is linked with a
+// RETAGGED relationship from the old commit to the new one. A tag pointing to a commit more recent than the
+// publication of its release must have been moved after the release, so its Version is marked as retagged
+func detectRetags(action string, hashes map[string]string, published map[string]time.Time, repoPath string, bindings *database.Bindings, driver neo4j.DriverWithContext, ctx context.Context) {
+	observed := time.Now()
+
+	for tag, hash := range hashes {
+		if previous, moved := bindings.Observe(action, tag, hash, observed); moved {
+			fmt.Printf(
+				"[ACTIONS] Tag \033[31m%s\033[0m of \033[31m%s\033[0m moved from %.7s to %.7s\n",
+				tag, action, previous.Sha, hash,
+			)
+
+			// Commits not saved as versions (e.g., the new target of a tag) are dated from the clone, if any
+			database.ExecuteQueryNeo(
+				`MERGE (o:Commit {full_name: $old})
+				ON CREATE SET o.name = $old_hash
+				SET o.date = coalesce(o.date, $old_date)
+				MERGE (n:Commit {full_name: $new})
+				ON CREATE SET n.name = $new_hash
+				SET n.date = coalesce(n.date, $new_date)
+				MERGE (o)-[r:RETAGGED {tag: $tag, observed: $observed}]->(n)
+				SET r.previously_observed = $previous`,
+				map[string]any{
+					"old":      action + "/" + previous.Sha,
+					"old_hash": previous.Sha,
+					"old_date": commitDate(repoPath, previous.Sha),
+					"new":      action + "/" + hash,
+					"new_hash": hash,
+					"new_date": commitDate(repoPath, hash),
+					"tag":      tag,
+					"observed": neo4j.LocalDateTimeOf(observed.UTC()),
+					"previous": neo4j.LocalDateTimeOf(previous.Observed.UTC()),
+				},
+				driver, ctx,
+			)
+		}
+
+		release, ok := published[tag]
+
+		if !ok {
+			continue
+		}
+
+		date, err := git.GetCommitDate(repoPath, hash)
+
+		if err != nil || !date.After(release) {
+			continue
+		}
+
+		fmt.Printf(
+			"[ACTIONS] Tag \033[31m%s\033[0m of \033[31m%s\033[0m points to a commit more recent than its release\n",
+			tag, action,
+		)
+
+		database.ExecuteQueryNeo(
+			`MATCH (v:Version {full_name: $version})
+			SET v.retagged = true, v.released = $released`,
+			map[string]any{
+				"version":  action + "/" + tag,
+				"released": neo4j.LocalDateTimeOf(release.UTC()),
+			},
+			driver, ctx,
+		)
+	}
+}
+
+// commitDate returns the date of a commit of a cloned repository as a neo4j local date time, or nil if there is no
+// clone or the commit is not part of it
+func commitDate(repoPath string, hash string) any {
+	if repoPath == "" {
+		return nil
+	}
+
+	date, err := git.GetCommitDate(repoPath, hash)
+
+	if err != nil {
+		return nil
+	}
+
+	return neo4j.LocalDateTimeOf(date)
+}
@@ -0,0 +1,128 @@
+package github
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultQuota is the quota assumed for a token whose remaining quota is not known yet
+const defaultQuota = 5000
+
+// A quota is the remaining number of calls of a token for a rate limit resource (e.g., core, search, or graphql)
+type quota struct {
+	remaining int
+	reset     time.Time
+}
+
+// A TokenPool keeps track of the remaining quota of several GitHub tokens (read from the rate limit headers of the
+// responses), so that each call uses the token with the most remaining quota
+type TokenPool struct {
+	mutex  sync.Mutex
+	tokens []string
+	quotas map[string]map[string]quota
+}
+
+// NewTokenPool returns a [TokenPool] containing the given tokens
+func NewTokenPool(tokens []string) *TokenPool {
+	pool := &TokenPool{tokens: tokens, quotas: map[string]map[string]quota{}}
+
+	for _, token := range tokens {
+		pool.quotas[token] = map[string]quota{}
+	}
+
+	return pool
+}
+
+// remaining returns the remaining quota of a token for a resource. The caller must hold the mutex
+func (p *TokenPool) remaining(token, resource string) int {
+	q, ok := p.quotas[token][resource]
+
+	if !ok || time.Now().After(q.reset) {
+		return defaultQuota
+	}
+
+	return q.remaining
+}
+
+// Next returns the token with the most remaining quota for the given resource. If all the tokens are exhausted, it
+// also returns how long to wait until the first of them is reset
+func (p *TokenPool) Next(resource string) (string, time.Duration) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if len(p.tokens) == 0 {
+		return "", 0
+	}
+
+	best := p.tokens[0]
+
+	for _, token := range p.tokens[1:] {
+		if p.remaining(token, resource) > p.remaining(best, resource) {
+			best = token
+		}
+	}
+
+	if p.remaining(best, resource) > 0 {
+		return best, 0
+	}
+
+	// All tokens are exhausted, pick the one that is reset first
+	for _, token := range p.tokens {
+		if p.quotas[token][resource].reset.Before(p.quotas[best][resource].reset) {
+			best = token
+		}
+	}
+
+	return best, max(time.Until(p.quotas[best][resource].reset)+time.Second, time.Second)
+}
+
+// Update records the remaining quota of a token from the rate limit headers of a response
+func (p *TokenPool) Update(token string, header http.Header) {
+	remaining, err := strconv.Atoi(header.Get("X-RateLimit-Remaining"))
+
+	if err != nil {
+		return
+	}
+
+	reset, err := strconv.ParseInt(header.Get("X-RateLimit-Reset"), 10, 64)
+
+	if err != nil {
+		return
+	}
+
+	resource := header.Get("X-RateLimit-Resource")
+
+	if resource == "" {
+		resource = "core"
+	}
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if _, ok := p.quotas[token]; ok {
+		p.quotas[token][resource] = quota{remaining: remaining, reset: time.Unix(reset, 0)}
+	}
+}
+
+// Exhaust marks a token as exhausted for a resource until the given time
+func (p *TokenPool) Exhaust(token, resource string, until time.Time) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if _, ok := p.quotas[token]; ok {
+		p.quotas[token][resource] = quota{remaining: 0, reset: until}
+	}
+}
+
+// resourceOf returns the rate limit resource used by a call to the given URI
+func resourceOf(uri string) string {
+	switch {
+	case strings.HasPrefix(uri, "search/"):
+		return "search"
+	default:
+		return "core"
+	}
+}
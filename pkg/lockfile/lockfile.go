@@ -0,0 +1,60 @@
+package lockfile
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Filenames are the names of the supported lockfiles, in order of precedence. An `npm-shrinkwrap.json` file takes
+// precedence over a `package-lock.json` one, as done by npm
+var Filenames = []string{"npm-shrinkwrap.json", "package-lock.json", "pnpm-lock.yaml", "yarn.lock"}
+
+// A Package is a package installed by a lockfile
+type Package struct {
+	Name    string
+	Version string
+}
+
+// Parse returns the packages installed by a lockfile given its name and content, sorted and without duplicates. The
+// root project and the local workspaces (and linked folders) are not packages
+func Parse(filename string, content []byte) ([]Package, error) {
+	var packages []Package
+	var err error
+
+	switch filename {
+	case "npm-shrinkwrap.json", "package-lock.json":
+		packages, err = parseNpm(content)
+	case "pnpm-lock.yaml":
+		packages, err = parsePnpm(content)
+	case "yarn.lock":
+		packages, err = parseYarn(content)
+	default:
+		return nil, fmt.Errorf("unsupported lockfile %s", filename)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", filename, err)
+	}
+
+	packages = slices.DeleteFunc(packages, func(p Package) bool {
+		return p.Name == "" || p.Version == ""
+	})
+
+	slices.SortFunc(packages, func(a, b Package) int {
+		return cmp.Or(cmp.Compare(a.Name, b.Name), cmp.Compare(a.Version, b.Version))
+	})
+
+	return slices.Compact(packages), nil
+}
+
+// splitSpec splits a package specifier (e.g., `@scope/name@^1.0.0`) in the name and range of the package. The range
+// might contain other specifiers (e.g., `name@patch:name@npm%3A1.0.0` in Yarn Berry)
+func splitSpec(spec string) (string, string) {
+	if i := strings.Index(spec[min(1, len(spec)):], "@"); i != -1 {
+		return spec[:i+1], spec[i+2:]
+	}
+
+	return spec, ""
+}
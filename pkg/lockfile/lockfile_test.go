@@ -0,0 +1,164 @@
+package lockfile
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		fixture  string
+		filename string
+		want     []Package
+	}{
+		{
+			fixture:  "npm-v1",
+			filename: "package-lock.json",
+			want: []Package{
+				{Name: "@actions/core", Version: "1.10.0"},
+				{Name: "@actions/http-client", Version: "2.0.1"},
+				{Name: "lru-cache", Version: "6.0.0"},
+				{Name: "semver", Version: "7.3.8"},
+				{Name: "string-width", Version: "4.2.3"},
+				{Name: "tunnel", Version: "0.0.6"},
+				{Name: "uuid", Version: "8.3.2"},
+			},
+		},
+		{
+			fixture:  "npm-v2",
+			filename: "package-lock.json",
+			want: []Package{
+				{Name: "@actions/core", Version: "1.10.0"},
+				{Name: "@actions/http-client", Version: "2.0.1"},
+				{Name: "semver", Version: "6.3.0"},
+				{Name: "string-width", Version: "4.2.3"},
+				{Name: "tunnel", Version: "0.0.6"},
+				{Name: "uuid", Version: "8.3.2"},
+			},
+		},
+		{
+			fixture:  "npm-v3",
+			filename: "package-lock.json",
+			want: []Package{
+				{Name: "@actions/core", Version: "1.11.1"},
+				{Name: "@actions/exec", Version: "1.1.1"},
+				{Name: "@actions/github", Version: "6.0.0"},
+				{Name: "@actions/http-client", Version: "2.2.3"},
+				{Name: "undici", Version: "5.28.4"},
+				{Name: "undici", Version: "6.21.0"},
+			},
+		},
+		{
+			fixture:  "npm-shrinkwrap",
+			filename: "npm-shrinkwrap.json",
+			want: []Package{
+				{Name: "tunnel", Version: "0.0.6"},
+			},
+		},
+		{
+			fixture:  "pnpm-v5",
+			filename: "pnpm-lock.yaml",
+			want: []Package{
+				{Name: "@actions/core", Version: "1.10.0"},
+				{Name: "@actions/glob", Version: "0.4.0"},
+				{Name: "@actions/http-client", Version: "2.0.1"},
+				{Name: "react", Version: "17.0.2"},
+				{Name: "react-dom", Version: "17.0.2"},
+				{Name: "semver", Version: "7.3.8"},
+				{Name: "tunnel", Version: "0.0.6"},
+				{Name: "uuid", Version: "8.3.2"},
+			},
+		},
+		{
+			fixture:  "pnpm-v6",
+			filename: "pnpm-lock.yaml",
+			want: []Package{
+				{Name: "@actions/core", Version: "1.10.1"},
+				{Name: "@actions/http-client", Version: "2.2.0"},
+				{Name: "@fastify/busboy", Version: "2.1.0"},
+				{Name: "js-tokens", Version: "4.0.0"},
+				{Name: "loose-envify", Version: "1.4.0"},
+				{Name: "react", Version: "18.2.0"},
+				{Name: "react-dom", Version: "18.2.0"},
+				{Name: "tunnel", Version: "0.0.6"},
+				{Name: "undici", Version: "5.28.2"},
+				{Name: "uuid", Version: "8.3.2"},
+			},
+		},
+		{
+			fixture:  "pnpm-v9",
+			filename: "pnpm-lock.yaml",
+			want: []Package{
+				{Name: "@actions/core", Version: "1.11.1"},
+				{Name: "@actions/exec", Version: "1.1.1"},
+				{Name: "react", Version: "18.3.1"},
+				{Name: "react-dom", Version: "18.3.1"},
+			},
+		},
+		{
+			fixture:  "yarn-classic",
+			filename: "yarn.lock",
+			want: []Package{
+				{Name: "@actions/core", Version: "1.10.0"},
+				{Name: "@actions/http-client", Version: "2.0.1"},
+				{Name: "string-width", Version: "4.2.3"},
+				{Name: "tunnel", Version: "0.0.6"},
+				{Name: "uuid", Version: "8.3.2"},
+			},
+		},
+		{
+			fixture:  "yarn-berry",
+			filename: "yarn.lock",
+			want: []Package{
+				{Name: "@actions/core", Version: "1.10.1"},
+				{Name: "@actions/http-client", Version: "2.2.0"},
+				{Name: "string-width", Version: "4.2.3"},
+				{Name: "tunnel", Version: "0.0.6"},
+				{Name: "uuid", Version: "8.3.2"},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.fixture, func(t *testing.T) {
+			content, err := os.ReadFile(filepath.Join("testdata", test.fixture, test.filename))
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			packages, err := Parse(test.filename, content)
+
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+
+			if !slices.Equal(packages, test.want) {
+				t.Errorf("Parse() = %v, want %v", packages, test.want)
+			}
+		})
+	}
+}
+
+func TestParseMalformed(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+	}{
+		{name: "npm", filename: "package-lock.json", content: `{"lockfileVersion": 3, "packages": `},
+		{name: "pnpm", filename: "pnpm-lock.yaml", content: "lockfileVersion: '9.0'\npackages: [\n"},
+		{name: "yarn berry", filename: "yarn.lock", content: "__metadata:\n  version: 8\n\"a@npm:1\": [\n"},
+		{name: "unsupported", filename: "bun.lockb", content: ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if packages, err := Parse(test.filename, []byte(test.content)); err == nil {
+				t.Errorf("Parse() = %v, want an error", packages)
+			}
+		})
+	}
+}
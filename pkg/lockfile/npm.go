@@ -0,0 +1,81 @@
+package lockfile
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// An npmLock is a `package-lock.json` or `npm-shrinkwrap.json` file. Version 1 lists the installed packages as a tree
+// of dependencies, while versions 2 and 3 list them by their path in `node_modules`
+type npmLock struct {
+	LockfileVersion int                      `json:"lockfileVersion"`
+	Packages        map[string]npmPackage    `json:"packages"`
+	Dependencies    map[string]npmDependency `json:"dependencies"`
+}
+
+// An npmPackage is an entry of the `packages` of an [npmLock] struct
+type npmPackage struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+	Link    bool   `json:"link"`
+}
+
+// An npmDependency is an entry of the `dependencies` of a version 1 [npmLock] struct
+type npmDependency struct {
+	Version      string                   `json:"version"`
+	Dependencies map[string]npmDependency `json:"dependencies"`
+}
+
+// parseNpm returns the packages installed by a `package-lock.json` or `npm-shrinkwrap.json` file
+func parseNpm(content []byte) ([]Package, error) {
+	var lock npmLock
+
+	if err := json.Unmarshal(content, &lock); err != nil {
+		return nil, err
+	}
+
+	packages := []Package{}
+
+	if lock.Packages != nil {
+		for path, pkg := range lock.Packages {
+			// The root project has an empty path, and workspaces are not in `node_modules`
+			if pkg.Link || !strings.Contains(path, "node_modules/") {
+				continue
+			}
+
+			name := path[strings.LastIndex(path, "node_modules/")+len("node_modules/"):]
+
+			// Aliased packages (e.g., `"alias": "npm:name@1.0.0"`) are installed under their alias
+			if pkg.Name != "" {
+				name = pkg.Name
+			}
+
+			packages = append(packages, Package{Name: name, Version: pkg.Version})
+		}
+
+		return packages, nil
+	}
+
+	var walk func(dependencies map[string]npmDependency)
+
+	walk = func(dependencies map[string]npmDependency) {
+		for name, dependency := range dependencies {
+			version := dependency.Version
+
+			if alias, ok := strings.CutPrefix(version, "npm:"); ok {
+				name, version = splitSpec(alias)
+			}
+
+			// Local dependencies are installed from a folder or tarball instead of the registry
+			if !strings.HasPrefix(version, "file:") {
+				packages = append(packages, Package{Name: name, Version: version})
+			}
+
+			walk(dependency.Dependencies)
+		}
+	}
+
+	walk(lock.Dependencies)
+
+	return packages, nil
+}
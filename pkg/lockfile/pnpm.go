@@ -0,0 +1,78 @@
+package lockfile
+
+import (
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// A pnpmLock is a `pnpm-lock.yaml` file. Its packages are keyed by `/name/version` up to version 5, by
+// `/name@version` in version 6, and by `name@version` from version 9, possibly followed by their peer dependencies
+type pnpmLock struct {
+	LockfileVersion any `yaml:"lockfileVersion"`
+	Packages        map[string]struct {
+		Name    string `yaml:"name"`
+		Version string `yaml:"version"`
+	} `yaml:"packages"`
+}
+
+// parsePnpm returns the packages installed by a `pnpm-lock.yaml` file
+func parsePnpm(content []byte) ([]Package, error) {
+	var lock pnpmLock
+
+	if err := yaml.Unmarshal(content, &lock); err != nil {
+		return nil, err
+	}
+
+	major, _, _ := strings.Cut(strings.Trim(toString(lock.LockfileVersion), `'"`), ".")
+	version, _ := strconv.Atoi(major)
+	packages := []Package{}
+
+	for key, pkg := range lock.Packages {
+		key = strings.TrimPrefix(key, "/")
+
+		// Peer dependencies are appended in parentheses (from version 6) or after an underscore (up to version 5)
+		key, _, _ = strings.Cut(key, "(")
+
+		name, resolved := splitSpec(key)
+
+		if version < 6 {
+			if i := strings.LastIndex(key, "/"); i > 0 {
+				name, resolved = key[:i], key[i+1:]
+				resolved, _, _ = strings.Cut(resolved, "_")
+			}
+		}
+
+		// Packages installed from tarballs or repositories are keyed by their URL, and declare their name and version
+		if pkg.Name != "" {
+			name = pkg.Name
+		}
+
+		if pkg.Version != "" {
+			resolved = pkg.Version
+		}
+
+		if strings.HasPrefix(resolved, "link:") || strings.HasPrefix(resolved, "file:") {
+			continue
+		}
+
+		packages = append(packages, Package{Name: name, Version: resolved})
+	}
+
+	return packages, nil
+}
+
+// toString returns a YAML scalar (e.g., the lockfile version, which is either a number or a string) as a string
+func toString(value any) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	}
+
+	return ""
+}
@@ -0,0 +1,90 @@
+package lockfile
+
+import (
+	"bufio"
+	"bytes"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// berryProtocols are the protocols of the Yarn Berry resolutions that are not installed from a registry
+var berryProtocols = []string{"workspace:", "link:", "portal:", "file:", "exec:"}
+
+// parseYarn returns the packages installed by a `yarn.lock` file, either in the classic (v1) or in the Berry (v2 and
+// later, a YAML document with a `__metadata` entry) format
+func parseYarn(content []byte) ([]Package, error) {
+	if bytes.Contains(content, []byte("\n__metadata:")) || bytes.HasPrefix(content, []byte("__metadata:")) {
+		return parseBerry(content)
+	}
+
+	packages := []Package{}
+	name := ""
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+
+	for scanner.Scan() {
+		line := scanner.Text()
+
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+			continue
+		case !strings.HasPrefix(line, " "):
+			// An entry starts with the comma-separated specifiers resolving to it (e.g., `"a@^1.0.0", a@^1.1.0:`)
+			first, _, _ := strings.Cut(strings.TrimSuffix(line, ":"), ",")
+			spec := ""
+			name, spec = splitSpec(strings.Trim(strings.TrimSpace(first), `"`))
+
+			// Aliased packages (e.g., `alias@npm:name@^1.0.0`) install the aliased package
+			if aliased, ok := strings.CutPrefix(spec, "npm:"); ok && strings.Contains(aliased[min(1, len(aliased)):], "@") {
+				name, _ = splitSpec(aliased)
+			}
+		case name != "" && strings.HasPrefix(strings.TrimSpace(line), "version "):
+			version := strings.Trim(strings.TrimPrefix(strings.TrimSpace(line), "version "), `"`)
+			packages = append(packages, Package{Name: name, Version: version})
+			name = ""
+		}
+	}
+
+	return packages, scanner.Err()
+}
+
+// parseBerry returns the packages installed by a Yarn Berry `yarn.lock` file
+func parseBerry(content []byte) ([]Package, error) {
+	var lock map[string]struct {
+		Version    string `yaml:"version"`
+		Resolution string `yaml:"resolution"`
+	}
+
+	if err := yaml.Unmarshal(content, &lock); err != nil {
+		return nil, err
+	}
+
+	packages := []Package{}
+
+	for key, entry := range lock {
+		if key == "__metadata" {
+			continue
+		}
+
+		// The resolution (e.g., `@scope/name@npm:1.0.0`) identifies the package that is actually installed
+		resolution := entry.Resolution
+
+		if resolution == "" {
+			resolution, _, _ = strings.Cut(key, ",")
+		}
+
+		name, protocol := splitSpec(strings.TrimSpace(resolution))
+		local := false
+
+		for _, prefix := range berryProtocols {
+			local = local || strings.HasPrefix(protocol, prefix)
+		}
+
+		if !local && entry.Version != "0.0.0-use.local" {
+			packages = append(packages, Package{Name: name, Version: entry.Version})
+		}
+	}
+
+	return packages, nil
+}
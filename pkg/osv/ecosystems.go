@@ -0,0 +1,32 @@
+package osv
+
+import (
+	"kleio/pkg/docker"
+	"strings"
+)
+
+// The OSV ecosystems of the components saved by kleio
+const (
+	EcosystemNpm     = "npm"
+	EcosystemActions = "GitHub Actions"
+	EcosystemBitnami = "Bitnami"
+)
+
+// ImagePackage returns the ecosystem, name, and version of the package distributed by a container image. Only the
+// images of Bitnami (`bitnami/<name>:<version>-<os>-r<revision>`) are packages of an OSV ecosystem, since the tags of
+// other images are not bound to the version of any package
+func ImagePackage(image docker.Image) (string, string, string, bool) {
+	name, ok := strings.CutPrefix(image.Repository, "bitnami/")
+
+	if image.Registry != docker.DefaultRegistry || !ok || image.Tag == "" {
+		return "", "", "", false
+	}
+
+	version, _, _ := strings.Cut(image.Tag, "-")
+
+	if _, ok := parseVersion(version); !ok {
+		return "", "", "", false
+	}
+
+	return EcosystemBitnami, name, version, true
+}
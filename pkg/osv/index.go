@@ -0,0 +1,340 @@
+package osv
+
+import (
+	"kleio/pkg/config"
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// An Index is the offline copy of the OSV database, made of the data dumps (`all.zip`) of some ecosystems stored in a
+// directory. Each dump is indexed by package when it is downloaded or imported, in a directory holding one JSON file
+// per package with the vulnerabilities affecting it, so that a lookup only reads the file of its package
+type Index struct {
+	dir    string
+	mutex  sync.Mutex
+	warned map[string]bool
+}
+
+// Open returns the [Index] struct of the data dumps stored in a directory
+func Open(dir string) *Index {
+	return &Index{dir: dir, warned: map[string]bool{}}
+}
+
+// dumpPath returns the path of the data dump of an ecosystem in a directory
+func dumpPath(dir string, ecosystem string) string {
+	return filepath.Join(dir, ecosystem+".zip")
+}
+
+// indexPath returns the path of the index of the data dump of an ecosystem in a directory
+func indexPath(dir string, ecosystem string) string {
+	return filepath.Join(dir, ecosystem)
+}
+
+// packagePath returns the path of the file listing the vulnerabilities of a package in the index of an ecosystem. The
+// (lowercase) name is escaped, so that the scopes of npm packages and the owners of Actions do not become directories
+func packagePath(dir string, ecosystem string, name string) string {
+	return filepath.Join(indexPath(dir, ecosystem), url.PathEscape(strings.ToLower(name))+".json")
+}
+
+// Has returns whether the data dump of an ecosystem was downloaded or imported, and indexed
+func (i *Index) Has(ecosystem string) bool {
+	if i == nil {
+		return false
+	}
+
+	info, err := os.Stat(indexPath(i.dir, ecosystem))
+
+	return err == nil && info.IsDir()
+}
+
+// Stored returns whether the data dump of an ecosystem was downloaded or imported, indexed or not (i.e., by a version
+// of kleio that did not index the dumps)
+func (i *Index) Stored(ecosystem string) bool {
+	if i == nil {
+		return false
+	}
+
+	_, err := os.Stat(dumpPath(i.dir, ecosystem))
+
+	return err == nil
+}
+
+// Lookup returns the vulnerabilities affecting a version of a package of an ecosystem, withdrawn ones included.
+// Ecosystems without an indexed data dump have no vulnerabilities (a warning is printed the first time one is looked
+// up)
+func (i *Index) Lookup(ecosystem string, name string, version string) ([]Vulnerability, error) {
+	vulnerabilities := []Vulnerability{}
+
+	if i == nil {
+		return vulnerabilities, nil
+	}
+
+	if !i.Has(ecosystem) {
+		i.warn(ecosystem)
+
+		return vulnerabilities, nil
+	}
+
+	names := []string{name}
+
+	// The advisories of a repository cover the Actions nested in it
+	if nameSplit := strings.SplitN(name, "/", 3); ecosystem == EcosystemActions && len(nameSplit) == 3 {
+		names = append(names, nameSplit[0]+"/"+nameSplit[1])
+	}
+
+	seen := map[string]bool{}
+
+	for _, key := range names {
+		candidates, err := readPackage(packagePath(i.dir, ecosystem, key))
+
+		if err != nil {
+			return nil, fmt.Errorf("reading the index of %s: %w", ecosystem, err)
+		}
+
+		for _, vulnerability := range candidates {
+			if !seen[vulnerability.Id] && vulnerability.Affects(ecosystem, name, version) {
+				seen[vulnerability.Id] = true
+				vulnerabilities = append(vulnerabilities, vulnerability)
+			}
+		}
+	}
+
+	return vulnerabilities, nil
+}
+
+// warn prints, once per ecosystem, why the vulnerabilities of an ecosystem cannot be looked up
+func (i *Index) warn(ecosystem string) {
+	i.mutex.Lock()
+	defer i.mutex.Unlock()
+
+	if i.warned[ecosystem] {
+		return
+	}
+
+	i.warned[ecosystem] = true
+
+	if i.Stored(ecosystem) {
+		fmt.Printf(
+			"\u001B[37m[OSV]\u001B[0m The data dump of \u001B[34m%s\u001B[0m in %s is not indexed, run `./kleio vulndb -offline` to index it\n",
+			ecosystem, i.dir,
+		)
+	} else {
+		fmt.Printf(
+			"\u001B[37m[OSV]\u001B[0m No data dump of \u001B[34m%s\u001B[0m in %s, run `./kleio vulndb` to download it\n",
+			ecosystem, i.dir,
+		)
+	}
+}
+
+// readPackage returns the vulnerabilities listed in the file of a package in an index. Packages without a file have
+// no vulnerabilities
+func readPackage(path string) ([]Vulnerability, error) {
+	content, err := os.ReadFile(path)
+
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var vulnerabilities []Vulnerability
+
+	return vulnerabilities, json.Unmarshal(content, &vulnerabilities)
+}
+
+// BuildIndex indexes the data dump of an ecosystem stored in the directory of the database (e.g., by a version of
+// kleio that did not index the dumps)
+func BuildIndex(cfg config.OSV, ecosystem string) error {
+	return buildIndex(cfg.Dir, ecosystem)
+}
+
+// buildIndex reads the data dump of an ecosystem in a directory, and writes its vulnerabilities in the files of the
+// packages they affect. The index is written next to the previous one, which is only replaced once it is complete.
+// Malformed entries are skipped (and reported), so that they do not discard the whole dump
+func buildIndex(dir string, ecosystem string) error {
+	reader, err := zip.OpenReader(dumpPath(dir, ecosystem))
+
+	if err != nil {
+		return err
+	}
+
+	defer reader.Close()
+
+	packages := map[string][]json.RawMessage{}
+
+	for _, file := range reader.File {
+		if !strings.HasSuffix(file.Name, ".json") {
+			continue
+		}
+
+		entry, vulnerability, err := readEntry(file)
+
+		if err != nil {
+			fmt.Printf(
+				"\u001B[37m[OSV]\u001B[0m Skipping %s of \u001B[34m%s\u001B[0m \u001B[31m𐄂\u001B[0m \u001B[34m(%v)\u001B[0m\n",
+				file.Name, ecosystem, err,
+			)
+
+			continue
+		}
+
+		names := map[string]bool{}
+
+		for _, affected := range vulnerability.Affected {
+			if name := strings.ToLower(affected.Package.Name); affected.Package.Ecosystem == ecosystem && !names[name] {
+				names[name] = true
+				packages[name] = append(packages[name], entry)
+			}
+		}
+	}
+
+	tmp, err := os.MkdirTemp(dir, "index-*")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.RemoveAll(tmp)
+
+	if err = os.Chmod(tmp, 0o755); err != nil {
+		return err
+	}
+
+	for name, entries := range packages {
+		content, err := json.Marshal(entries)
+
+		if err != nil {
+			return err
+		}
+
+		if err = os.WriteFile(filepath.Join(tmp, url.PathEscape(name)+".json"), content, 0o644); err != nil {
+			return err
+		}
+	}
+
+	stale := tmp + "-stale"
+
+	if err = os.Rename(indexPath(dir, ecosystem), stale); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	defer os.RemoveAll(stale)
+
+	return os.Rename(tmp, indexPath(dir, ecosystem))
+}
+
+// readEntry reads an entry of a data dump, and returns its content along with the vulnerability it decodes to
+func readEntry(file *zip.File) (json.RawMessage, *Vulnerability, error) {
+	entry, err := file.Open()
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	defer entry.Close()
+
+	content, err := io.ReadAll(entry)
+
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vulnerability := &Vulnerability{}
+
+	if err = json.Unmarshal(content, vulnerability); err != nil {
+		return nil, nil, err
+	}
+
+	return content, vulnerability, nil
+}
+
+// client downloads the data dumps. Its timeout covers the whole download, so it leaves room for the largest dumps
+// (e.g., the one of npm)
+var client = &http.Client{Timeout: 10 * time.Minute}
+
+// Download downloads the data dump of an ecosystem in the directory of the database and indexes it, replacing the
+// previous one only once the download is complete
+func Download(cfg config.OSV, ecosystem string) error {
+	uri, err := url.JoinPath(cfg.URL, ecosystem, "all.zip")
+
+	if err != nil {
+		return err
+	}
+
+	res, err := client.Get(uri)
+
+	if err != nil {
+		return err
+	}
+
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s: %s", uri, res.Status)
+	}
+
+	return store(cfg.Dir, ecosystem, res.Body)
+}
+
+// Import copies the data dump of an ecosystem (e.g., downloaded beforehand) in the directory of the database and
+// indexes it
+func Import(cfg config.OSV, ecosystem string, path string) error {
+	f, err := os.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	return store(cfg.Dir, ecosystem, f)
+}
+
+// store writes the data dump of an ecosystem in a directory, after checking that it is a valid zip archive, and indexes
+// it
+func store(dir string, ecosystem string, dump io.Reader) error {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(dir, "download-*.zip")
+
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	size, err := io.Copy(tmp, dump)
+
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		return err
+	}
+
+	reader, err := zip.OpenReader(tmp.Name())
+
+	if err != nil {
+		return fmt.Errorf("invalid data dump of %d bytes: %w", size, err)
+	}
+
+	_ = reader.Close()
+
+	if err = os.Rename(tmp.Name(), dumpPath(dir, ecosystem)); err != nil {
+		return err
+	}
+
+	return buildIndex(dir, ecosystem)
+}
@@ -0,0 +1,205 @@
+package osv
+
+import (
+	"kleio/pkg/config"
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// writeDump writes a data dump made of the given entries (keyed by file name) and returns its path
+func writeDump(t *testing.T, entries map[string]string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "all.zip")
+	f, err := os.Create(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writer := zip.NewWriter(f)
+
+	for name, content := range entries {
+		entry, err := writer.Create(name)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if _, err = entry.Write([]byte(content)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err = writer.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err = f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	return path
+}
+
+func TestIndexLookup(t *testing.T) {
+	cfg := config.OSV{Dir: t.TempDir()}
+	dump := writeDump(t, map[string]string{
+		"GHSA-npm1.json": `{"id": "GHSA-npm1", "affected": [
+			{"package": {"ecosystem": "npm", "name": "@actions/core"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "1.9.1"}]}]},
+			{"package": {"ecosystem": "npm", "name": "@actions/core"}, "ranges": [{"type": "SEMVER", "events": [{"introduced": "2.0.0"}, {"fixed": "2.0.1"}]}]}
+		]}`,
+		"GHSA-npm2.json": `{"id": "GHSA-npm2", "affected": [
+			{"package": {"ecosystem": "npm", "name": "tunnel"}, "versions": ["0.0.6"]}
+		]}`,
+		"GHSA-malformed.json": `{"id": `,
+		"README.md":           "not an entry",
+	})
+
+	if err := Import(cfg, EcosystemNpm, dump); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	index := Open(cfg.Dir)
+
+	if !index.Has(EcosystemNpm) || index.Has(EcosystemActions) {
+		t.Fatalf("Has() = %v, %v, want true, false", index.Has(EcosystemNpm), index.Has(EcosystemActions))
+	}
+
+	tests := []struct {
+		name    string
+		pkg     string
+		version string
+		want    []string
+	}{
+		{name: "affected", pkg: "@actions/core", version: "1.9.0", want: []string{"GHSA-npm1"}},
+		{name: "affected by a second range", pkg: "@actions/core", version: "2.0.0", want: []string{"GHSA-npm1"}},
+		{name: "fixed", pkg: "@actions/core", version: "1.9.1", want: []string{}},
+		{name: "listed version", pkg: "tunnel", version: "0.0.6", want: []string{"GHSA-npm2"}},
+		{name: "unknown package", pkg: "left-pad", version: "1.0.0", want: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vulnerabilities, err := index.Lookup(EcosystemNpm, test.pkg, test.version)
+
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+
+			ids := []string{}
+
+			for _, vulnerability := range vulnerabilities {
+				ids = append(ids, vulnerability.Id)
+			}
+
+			if !slices.Equal(ids, test.want) {
+				t.Errorf("Lookup(%q, %q) = %v, want %v", test.pkg, test.version, ids, test.want)
+			}
+		})
+	}
+}
+
+func TestIndexLookupActions(t *testing.T) {
+	cfg := config.OSV{Dir: t.TempDir()}
+	dump := writeDump(t, map[string]string{
+		"GHSA-repo.json": `{"id": "GHSA-repo", "affected": [
+			{"package": {"ecosystem": "GitHub Actions", "name": "Owner/Repo"}, "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"fixed": "2.0.0"}]}]}
+		]}`,
+		"GHSA-nested.json": `{"id": "GHSA-nested", "affected": [
+			{"package": {"ecosystem": "GitHub Actions", "name": "owner/repo/init"}, "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "0"}, {"last_affected": "1.5.0"}]}]}
+		]}`,
+	})
+
+	if err := Import(cfg, EcosystemActions, dump); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	index := Open(cfg.Dir)
+
+	tests := []struct {
+		name    string
+		action  string
+		version string
+		want    []string
+	}{
+		{name: "repository", action: "owner/repo", version: "v1.0.0", want: []string{"GHSA-repo"}},
+		{name: "nested Action", action: "owner/repo/init", version: "v1.0.0", want: []string{"GHSA-nested", "GHSA-repo"}},
+		{name: "nested Action after its last affected version", action: "owner/repo/init", version: "v1.6.0", want: []string{"GHSA-repo"}},
+		{name: "other nested Action", action: "OWNER/repo/analyze", version: "v1.0.0", want: []string{"GHSA-repo"}},
+		{name: "fixed", action: "owner/repo/init", version: "v2.0.0", want: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			vulnerabilities, err := index.Lookup(EcosystemActions, test.action, test.version)
+
+			if err != nil {
+				t.Fatalf("Lookup() error = %v", err)
+			}
+
+			ids := []string{}
+
+			for _, vulnerability := range vulnerabilities {
+				ids = append(ids, vulnerability.Id)
+			}
+
+			if !slices.Equal(ids, test.want) {
+				t.Errorf("Lookup(%q, %q) = %v, want %v", test.action, test.version, ids, test.want)
+			}
+		})
+	}
+}
+
+func TestIndexMissing(t *testing.T) {
+	cfg := config.OSV{Dir: t.TempDir()}
+	dump := writeDump(t, map[string]string{
+		"GHSA-npm.json": `{"id": "GHSA-npm", "affected": [
+			{"package": {"ecosystem": "npm", "name": "tunnel"}, "versions": ["0.0.6"]}
+		]}`,
+	})
+
+	// A dump stored without its index (i.e., by a version that did not index the dumps) is only looked up once indexed
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+
+	content, err := os.ReadFile(dump)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err = os.WriteFile(dumpPath(cfg.Dir, EcosystemNpm), content, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	index := Open(cfg.Dir)
+
+	if !index.Stored(EcosystemNpm) || index.Has(EcosystemNpm) {
+		t.Fatalf("Stored(), Has() = %v, %v, want true, false", index.Stored(EcosystemNpm), index.Has(EcosystemNpm))
+	}
+
+	if vulnerabilities, err := index.Lookup(EcosystemNpm, "tunnel", "0.0.6"); err != nil || len(vulnerabilities) != 0 {
+		t.Errorf("Lookup() = %v, %v, want no vulnerabilities", vulnerabilities, err)
+	}
+
+	if err = BuildIndex(cfg, EcosystemNpm); err != nil {
+		t.Fatalf("BuildIndex() error = %v", err)
+	}
+
+	if vulnerabilities, err := index.Lookup(EcosystemNpm, "tunnel", "0.0.6"); err != nil || len(vulnerabilities) != 1 {
+		t.Errorf("Lookup() = %v, %v, want GHSA-npm", vulnerabilities, err)
+	}
+
+	if err = Import(cfg, EcosystemNpm, writeDump(t, map[string]string{})); err != nil {
+		t.Fatalf("Import() error = %v", err)
+	}
+
+	if vulnerabilities, err := index.Lookup(EcosystemNpm, "tunnel", "0.0.6"); err != nil || len(vulnerabilities) != 0 {
+		t.Errorf("Lookup() = %v, %v, want no vulnerabilities once the dump is replaced", vulnerabilities, err)
+	}
+}
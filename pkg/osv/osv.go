@@ -0,0 +1,266 @@
+package osv
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	gocvss20 "github.com/pandatix/go-cvss/20"
+	gocvss31 "github.com/pandatix/go-cvss/31"
+	gocvss40 "github.com/pandatix/go-cvss/40"
+)
+
+// A Vulnerability is an entry of the OSV database (see https://ossf.github.io/osv-schema/)
+type Vulnerability struct {
+	Id        string     `json:"id"`
+	Aliases   []string   `json:"aliases"`
+	Summary   string     `json:"summary"`
+	Published time.Time  `json:"published"`
+	Modified  time.Time  `json:"modified"`
+	Withdrawn *time.Time `json:"withdrawn"`
+	Severity  []Severity `json:"severity"`
+	Affected  []Affected `json:"affected"`
+	Database  struct {
+		CWEs     []string `json:"cwe_ids"`
+		Severity string   `json:"severity"`
+	} `json:"database_specific"`
+}
+
+// A Severity is a score of a [Vulnerability] struct, such as a CVSS vector
+type Severity struct {
+	Type  string `json:"type"`
+	Score string `json:"score"`
+}
+
+// An Affected is a package affected by a [Vulnerability] struct, with the ranges of its affected versions
+type Affected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges   []Range  `json:"ranges"`
+	Versions []string `json:"versions"`
+}
+
+// A Range is a list of events (introductions and fixes) delimiting the affected versions of a package
+type Range struct {
+	Type   string  `json:"type"`
+	Events []Event `json:"events"`
+}
+
+// An Event is the version introducing or fixing a [Vulnerability] struct. Only one of its fields is set
+type Event struct {
+	Introduced   string `json:"introduced,omitempty"`
+	Fixed        string `json:"fixed,omitempty"`
+	LastAffected string `json:"last_affected,omitempty"`
+	Limit        string `json:"limit,omitempty"`
+}
+
+// IsWithdrawn returns whether the [Vulnerability] struct was withdrawn
+func (v *Vulnerability) IsWithdrawn() bool {
+	return v.Withdrawn != nil && !v.Withdrawn.IsZero()
+}
+
+// CVE returns the CVE identifier of the [Vulnerability] struct, or the empty string if it has none
+func (v *Vulnerability) CVE() string {
+	if strings.HasPrefix(v.Id, "CVE-") {
+		return v.Id
+	}
+
+	for _, alias := range v.Aliases {
+		if strings.HasPrefix(alias, "CVE-") {
+			return alias
+		}
+	}
+
+	return ""
+}
+
+// CVSS returns the base score of the first valid CVSS vector of the [Vulnerability] struct, or 0 if it has none
+func (v *Vulnerability) CVSS() float64 {
+	for _, severity := range v.Severity {
+		switch severity.Type {
+		case "CVSS_V4":
+			if vector, err := gocvss40.ParseVector(severity.Score); err == nil {
+				return vector.Score()
+			}
+		case "CVSS_V3":
+			if vector, err := gocvss31.ParseVector(severity.Score); err == nil {
+				return vector.BaseScore()
+			}
+		case "CVSS_V2":
+			if vector, err := gocvss20.ParseVector(severity.Score); err == nil {
+				return vector.BaseScore()
+			}
+		}
+	}
+
+	return 0
+}
+
+// Affects returns whether the [Vulnerability] struct affects a version of a package of an ecosystem. The version must
+// either be listed explicitly, or fall in one of the `SEMVER` or `ECOSYSTEM` ranges (`GIT` ranges are not supported)
+func (v *Vulnerability) Affects(ecosystem string, name string, ver string) bool {
+	for _, affected := range v.Affected {
+		if affected.Package.Ecosystem != ecosystem || !samePackage(ecosystem, affected.Package.Name, name) {
+			continue
+		}
+
+		if slices.Contains(affected.Versions, ver) || slices.Contains(affected.Versions, strings.TrimPrefix(ver, "v")) {
+			return true
+		}
+
+		for _, r := range affected.Ranges {
+			if (r.Type == "SEMVER" || r.Type == "ECOSYSTEM") && r.contains(ver) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// Ranges returns the affected ranges (e.g., `>=1.0.0, <1.2.3`, or `=1.0.0` for the versions listed without any range)
+// and the fixed versions of a package of an ecosystem in the [Vulnerability] struct
+func (v *Vulnerability) Ranges(ecosystem string, name string) ([]string, []string) {
+	affected, fixed := []string{}, []string{}
+
+	for _, a := range v.Affected {
+		if a.Package.Ecosystem != ecosystem || !samePackage(ecosystem, a.Package.Name, name) {
+			continue
+		}
+
+		for _, r := range a.Ranges {
+			if r.Type != "SEMVER" && r.Type != "ECOSYSTEM" {
+				continue
+			}
+
+			// The bounds are nil until the first introduction. The introduction at version 0 has no lower bound, and
+			// affects all the versions if never fixed
+			var bounds []string
+
+			flush := func() {
+				switch {
+				case bounds == nil:
+				case len(bounds) == 0:
+					affected = append(affected, ">=0")
+				default:
+					affected = append(affected, strings.Join(bounds, ", "))
+				}
+			}
+
+			for _, e := range r.Events {
+				switch {
+				case e.Introduced != "":
+					flush()
+
+					if bounds = []string{}; e.Introduced != "0" {
+						bounds = append(bounds, ">="+e.Introduced)
+					}
+				case e.Fixed != "":
+					bounds = append(bounds, "<"+e.Fixed)
+					fixed = append(fixed, e.Fixed)
+				case e.LastAffected != "":
+					bounds = append(bounds, "<="+e.LastAffected)
+				case e.Limit != "":
+					bounds = append(bounds, "<"+e.Limit)
+				}
+			}
+
+			flush()
+		}
+
+		// The versions in the ranges are often enumerated as well, and are only listed when there is no range
+		if len(a.Ranges) == 0 {
+			for _, version := range a.Versions {
+				affected = append(affected, "="+version)
+			}
+		}
+	}
+
+	return slices.Compact(affected), slices.Compact(fixed)
+}
+
+// samePackage returns whether the package b is the package a of an advisory in an ecosystem. The names of GitHub
+// repositories (and thus of Actions) are case-insensitive, and the advisories of a repository cover the Actions nested
+// in it (e.g., `github/codeql-action/init`)
+func samePackage(ecosystem string, a string, b string) bool {
+	if ecosystem == EcosystemActions {
+		return strings.EqualFold(a, b) || strings.HasPrefix(strings.ToLower(b), strings.ToLower(a)+"/")
+	}
+
+	return a == b
+}
+
+// contains returns whether a version falls in the [Range] struct. The events are sorted by version, and the version is
+// affected if the closest event preceding it is an introduction (or if it is the last affected version)
+func (r Range) contains(raw string) bool {
+	target, ok := parseVersion(raw)
+
+	if !ok {
+		return false
+	}
+
+	// The version 0 precedes all the versions, pre-releases of 0.0.0 included
+	type event struct {
+		version version
+		lowest  bool
+		kind    string
+	}
+
+	events := []event{}
+
+	for _, e := range r.Events {
+		kind, value := "introduced", e.Introduced
+
+		switch {
+		case e.Fixed != "":
+			kind, value = "fixed", e.Fixed
+		case e.LastAffected != "":
+			kind, value = "last_affected", e.LastAffected
+		case e.Limit != "":
+			kind, value = "limit", e.Limit
+		}
+
+		// The introduction at version 0 affects all the versions
+		if value == "0" {
+			events = append(events, event{lowest: true, kind: kind})
+		} else if parsed, ok := parseVersion(value); ok {
+			events = append(events, event{version: parsed, kind: kind})
+		}
+	}
+
+	slices.SortStableFunc(events, func(a, b event) int {
+		switch {
+		case a.lowest && b.lowest:
+			return 0
+		case a.lowest:
+			return -1
+		case b.lowest:
+			return 1
+		}
+
+		return compareVersions(a.version, b.version)
+	})
+
+	affected := false
+
+	for _, e := range events {
+		c := 1
+
+		if !e.lowest {
+			c = compareVersions(target, e.version)
+		}
+
+		switch {
+		case e.kind == "introduced" && c >= 0:
+			affected = true
+		case (e.kind == "fixed" || e.kind == "limit") && c >= 0:
+			affected = false
+		case e.kind == "last_affected" && c > 0:
+			affected = false
+		}
+	}
+
+	return affected
+}
@@ -0,0 +1,119 @@
+package osv
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRangeContains(t *testing.T) {
+	tests := []struct {
+		name     string
+		events   []Event
+		affected []string
+		safe     []string
+	}{
+		{
+			name:     "introduced and fixed",
+			events:   []Event{{Introduced: "1.2.0"}, {Fixed: "1.4.1"}},
+			affected: []string{"1.2.0", "v1.3", "1.4.1-rc.1", "1.4.0"},
+			safe:     []string{"1.1.9", "1.2.0-beta", "1.4.1", "2.0.0"},
+		},
+		{
+			name:     "introduced at 0",
+			events:   []Event{{Introduced: "0"}, {Fixed: "1.0.0"}},
+			affected: []string{"0.0.0", "0.0.1", "0.9.9", "1.0.0-rc.1"},
+			safe:     []string{"1.0.0", "1.0.1"},
+		},
+		{
+			name:     "introduced at 0 with pre-releases of 0.0.0",
+			events:   []Event{{Introduced: "0"}, {Fixed: "0.0.0-beta"}},
+			affected: []string{"0.0.0-alpha", "0.0.0-0"},
+			safe:     []string{"0.0.0-beta", "0.0.0", "1.0.0"},
+		},
+		{
+			name:     "introduced at 0 and never fixed",
+			events:   []Event{{Introduced: "0"}},
+			affected: []string{"0.0.0-alpha", "0.0.0", "3.1.4", "99.0.0"},
+		},
+		{
+			name:     "last affected",
+			events:   []Event{{Introduced: "2.0.0"}, {LastAffected: "2.3.0"}},
+			affected: []string{"2.0.0", "2.3.0", "2.3.0-rc.1"},
+			safe:     []string{"1.9.9", "2.3.1", "2.4.0-alpha"},
+		},
+		{
+			name:     "introduced at 0 until the last affected version",
+			events:   []Event{{Introduced: "0"}, {LastAffected: "0.5.0"}},
+			affected: []string{"0.0.0-alpha", "0.5.0"},
+			safe:     []string{"0.5.1"},
+		},
+		{
+			name:     "limit",
+			events:   []Event{{Introduced: "1.0.0"}, {Limit: "1.5.0"}},
+			affected: []string{"1.0.0", "1.4.9"},
+			safe:     []string{"1.5.0", "1.6.0"},
+		},
+		{
+			name:     "several windows",
+			events:   []Event{{Introduced: "0"}, {Fixed: "1.0.1"}, {Introduced: "2.0.0"}, {Fixed: "2.0.3"}},
+			affected: []string{"0.1.0", "1.0.0", "2.0.0", "2.0.2"},
+			safe:     []string{"1.0.1", "1.9.0", "2.0.0-rc.1", "2.0.3"},
+		},
+		{
+			name:     "unsorted events",
+			events:   []Event{{Fixed: "3.0.0"}, {Introduced: "2.5.0"}},
+			affected: []string{"2.5.0", "2.9.9"},
+			safe:     []string{"2.4.0", "3.0.0"},
+		},
+		{
+			name:   "invalid versions",
+			events: []Event{{Introduced: "0"}},
+			safe:   []string{"", "main", "1.2.3.4"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := Range{Type: "SEMVER", Events: test.events}
+
+			for _, v := range test.affected {
+				if !r.contains(v) {
+					t.Errorf("contains(%q) = false, want true", v)
+				}
+			}
+
+			for _, v := range test.safe {
+				if r.contains(v) {
+					t.Errorf("contains(%q) = true, want false", v)
+				}
+			}
+		})
+	}
+}
+
+func TestVulnerabilityRanges(t *testing.T) {
+	affected := func(name string, ranges []Range, versions ...string) Affected {
+		a := Affected{Ranges: ranges, Versions: versions}
+		a.Package.Ecosystem, a.Package.Name = EcosystemNpm, name
+
+		return a
+	}
+
+	v := Vulnerability{Affected: []Affected{
+		affected("pkg", []Range{{Type: "SEMVER", Events: []Event{{Introduced: "0"}, {Fixed: "1.0.1"}, {Introduced: "2.0.0"}}}}),
+		affected("pkg", []Range{{Type: "ECOSYSTEM", Events: []Event{{Introduced: "3.0.0"}, {LastAffected: "3.1.0"}}}}),
+		affected("pkg", []Range{{Type: "GIT", Events: []Event{{Introduced: "abc"}, {Fixed: "def"}}}}),
+		affected("pkg", nil, "4.0.0", "4.0.1"),
+		affected("other", []Range{{Type: "SEMVER", Events: []Event{{Introduced: "0"}}}}),
+	}}
+
+	ranges, fixed := v.Ranges(EcosystemNpm, "pkg")
+
+	if want := []string{"<1.0.1", ">=2.0.0", ">=3.0.0, <=3.1.0", "=4.0.0", "=4.0.1"}; !slices.Equal(ranges, want) {
+		t.Errorf("Ranges() affected = %q, want %q", ranges, want)
+	}
+
+	if want := []string{"1.0.1"}; !slices.Equal(fixed, want) {
+		t.Errorf("Ranges() fixed = %q, want %q", fixed, want)
+	}
+}
@@ -0,0 +1,86 @@
+package osv
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+)
+
+// A version is a semantic version, whose missing minor and patch numbers are zero
+type version struct {
+	numbers    [3]int
+	prerelease []string
+}
+
+// parseVersion parses a semantic version, optionally prefixed by `v` (e.g., `v1.2` or `1.2.3-beta.1+build`). Build
+// metadata is ignored
+func parseVersion(raw string) (version, bool) {
+	v := version{}
+	raw = strings.TrimPrefix(strings.TrimPrefix(strings.TrimSpace(raw), "v"), "V")
+	raw, _, _ = strings.Cut(raw, "+")
+	raw, prerelease, hasPrerelease := strings.Cut(raw, "-")
+	numbers := strings.Split(raw, ".")
+
+	if raw == "" || len(numbers) > 3 {
+		return v, false
+	}
+
+	for i, number := range numbers {
+		parsed, err := strconv.Atoi(number)
+
+		if err != nil || parsed < 0 {
+			return v, false
+		}
+
+		v.numbers[i] = parsed
+	}
+
+	if hasPrerelease {
+		v.prerelease = strings.Split(prerelease, ".")
+	}
+
+	return v, true
+}
+
+// compareVersions compares two semantic versions. Pre-releases precede their release, and their identifiers are
+// compared numerically when both are numbers, and lexically otherwise
+func compareVersions(a, b version) int {
+	for i := range a.numbers {
+		if c := cmp.Compare(a.numbers[i], b.numbers[i]); c != 0 {
+			return c
+		}
+	}
+
+	switch {
+	case len(a.prerelease) == 0 && len(b.prerelease) == 0:
+		return 0
+	case len(a.prerelease) == 0:
+		return 1
+	case len(b.prerelease) == 0:
+		return -1
+	}
+
+	for i := 0; i < len(a.prerelease) && i < len(b.prerelease); i++ {
+		x, errX := strconv.Atoi(a.prerelease[i])
+		y, errY := strconv.Atoi(b.prerelease[i])
+
+		var c int
+
+		switch {
+		case errX == nil && errY == nil:
+			c = cmp.Compare(x, y)
+		case errX == nil:
+			c = -1
+		case errY == nil:
+			c = 1
+		default:
+			c = strings.Compare(a.prerelease[i], b.prerelease[i])
+		}
+
+		if c != 0 {
+			return c
+		}
+	}
+
+	return cmp.Compare(len(a.prerelease), len(b.prerelease))
+}
@@ -0,0 +1,73 @@
+package osv
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseVersion(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want version
+		ok   bool
+	}{
+		{raw: "1.2.3", want: version{numbers: [3]int{1, 2, 3}}, ok: true},
+		{raw: "v1.2", want: version{numbers: [3]int{1, 2, 0}}, ok: true},
+		{raw: "V4", want: version{numbers: [3]int{4, 0, 0}}, ok: true},
+		{raw: "1.2.3-beta.1", want: version{numbers: [3]int{1, 2, 3}, prerelease: []string{"beta", "1"}}, ok: true},
+		{raw: "1.2.3+build.5", want: version{numbers: [3]int{1, 2, 3}}, ok: true},
+		{raw: "0.0.0-alpha+build", want: version{prerelease: []string{"alpha"}}, ok: true},
+		{raw: "", ok: false},
+		{raw: "main", ok: false},
+		{raw: "1.2.3.4", ok: false},
+		{raw: "1.-2", ok: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.raw, func(t *testing.T) {
+			v, ok := parseVersion(test.raw)
+
+			if ok != test.ok {
+				t.Fatalf("parseVersion(%q) ok = %v, want %v", test.raw, ok, test.ok)
+			}
+
+			if ok && (v.numbers != test.want.numbers || !slices.Equal(v.prerelease, test.want.prerelease)) {
+				t.Errorf("parseVersion(%q) = %+v, want %+v", test.raw, v, test.want)
+			}
+		})
+	}
+}
+
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a    string
+		b    string
+		want int
+	}{
+		{a: "1.2.3", b: "1.2.3", want: 0},
+		{a: "v1.2", b: "1.2.0", want: 0},
+		{a: "1.2.3", b: "1.10.0", want: -1},
+		{a: "2.0.0", b: "1.99.99", want: 1},
+		{a: "1.0.0-alpha", b: "1.0.0", want: -1},
+		{a: "1.0.0-alpha", b: "1.0.0-alpha.1", want: -1},
+		{a: "1.0.0-alpha.1", b: "1.0.0-alpha.beta", want: -1},
+		{a: "1.0.0-beta.2", b: "1.0.0-beta.11", want: -1},
+		{a: "1.0.0-rc.1", b: "1.0.0-beta.11", want: 1},
+		{a: "0.0.0-alpha", b: "0.0.0", want: -1},
+	}
+
+	for _, test := range tests {
+		t.Run(test.a+" "+test.b, func(t *testing.T) {
+			a, _ := parseVersion(test.a)
+			b, _ := parseVersion(test.b)
+
+			if c := compareVersions(a, b); c != test.want {
+				t.Errorf("compareVersions(%q, %q) = %d, want %d", test.a, test.b, c, test.want)
+			}
+
+			if c := compareVersions(b, a); c != -test.want {
+				t.Errorf("compareVersions(%q, %q) = %d, want %d", test.b, test.a, c, -test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package vulns
+
+import (
+	"kleio/pkg/osv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aegis-forge/cage"
+)
+
+// GHSA is the [VulnerabilitySource] of the GitHub Advisory Database, which is queried through the API for the
+// advisories of Actions. The advisories of an Action are retrieved once, and then matched against all its versions
+type GHSA struct {
+	token      string
+	once       sync.Once
+	err        error
+	source     cage.Github
+	mutex      sync.Mutex
+	advisories map[string][]cage.Vulnerability
+}
+
+// NewGHSA returns the [GHSA] source, calling the GitHub API with token
+func NewGHSA(token string) *GHSA {
+	return &GHSA{token: token, advisories: map[string][]cage.Vulnerability{}}
+}
+
+// Name returns the name of the [GHSA] source
+func (g *GHSA) Name() string {
+	return "ghsa"
+}
+
+// Lookup returns the vulnerabilities affecting a version of an Action. Other ecosystems have no vulnerabilities. cage
+// does not expose the severity label, the last modification, or the withdrawal of the advisories, so their severity is
+// derived from their CVSS score and they are never withdrawn (the OSV source, which mirrors the GitHub Advisory
+// Database, reports them when it takes precedence)
+func (g *GHSA) Lookup(ecosystem string, name string, version string) ([]Vulnerability, error) {
+	vendor, product, ok := strings.Cut(name, "/")
+
+	if ecosystem != osv.EcosystemActions || !ok {
+		return nil, nil
+	}
+
+	// Actions in subdirectories share the advisories of their repository
+	product, _, _ = strings.Cut(product, "/")
+
+	semver, err := cage.NewSemver(version)
+
+	if err != nil {
+		return nil, nil
+	}
+
+	pkg, err := cage.NewPackage(vendor, product, time.Time{}, semver)
+
+	if err != nil {
+		return nil, err
+	}
+
+	advisories, err := g.fetch(vendor+"/"+product, *pkg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	matched, err := g.source.CompareVulnerabilities(advisories, *pkg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	vulnerabilities := []Vulnerability{}
+
+	for _, advisory := range matched {
+		vulnerability := Vulnerability{
+			Id:        advisory.Id,
+			Aliases:   union(nil, []string{advisory.Cve}),
+			CWEs:      advisory.Cwes,
+			CVSS:      float64(advisory.Cvss),
+			Published: advisory.Published,
+			Affected:  []string{},
+			Fixed:     []string{},
+			Sources:   []string{g.Name()},
+		}
+
+		vulnerability.Severity = severity("", vulnerability.CVSS)
+
+		for _, r := range advisory.RangesVulnerable {
+			vulnerability.Affected = append(vulnerability.Affected, formatRange(r))
+		}
+
+		for _, r := range advisory.RangesPatched {
+			if r.Start != "" {
+				vulnerability.Fixed = append(vulnerability.Fixed, string(r.Start))
+			}
+		}
+
+		vulnerabilities = append(vulnerabilities, vulnerability)
+	}
+
+	return vulnerabilities, nil
+}
+
+// formatRange returns a range of versions of an advisory in the format of [osv.Vulnerability.Ranges]
+func formatRange(r cage.VersionRange) string {
+	if r.Start == r.End && r.IncludeLeft && r.IncludeRight {
+		return "=" + string(r.Start)
+	}
+
+	bounds := []string{}
+
+	// The ranges without a lower bound start from version 0.0.0, and have no lower bound in OSV either
+	if r.Start != "" && !r.Start.Equals("0.0.0") {
+		if r.IncludeLeft {
+			bounds = append(bounds, ">="+string(r.Start))
+		} else {
+			bounds = append(bounds, ">"+string(r.Start))
+		}
+	}
+
+	if r.End != "" {
+		if r.IncludeRight {
+			bounds = append(bounds, "<="+string(r.End))
+		} else {
+			bounds = append(bounds, "<"+string(r.End))
+		}
+	}
+
+	if len(bounds) == 0 {
+		return ">=0"
+	}
+
+	return strings.Join(bounds, ", ")
+}
+
+// fetch returns the advisories of the repository of an Action, retrieving them the first time it is looked up
+func (g *GHSA) fetch(repository string, pkg cage.Package) ([]cage.Vulnerability, error) {
+	g.once.Do(func() {
+		g.err = g.source.SetToken(g.token)
+	})
+
+	if g.err != nil {
+		return nil, g.err
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	if advisories, ok := g.advisories[repository]; ok {
+		return advisories, nil
+	}
+
+	advisories, err := g.source.GetVulnerabilities(pkg)
+
+	if err != nil {
+		return nil, err
+	}
+
+	g.advisories[repository] = advisories
+
+	return advisories, nil
+}
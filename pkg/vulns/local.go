@@ -0,0 +1,106 @@
+package vulns
+
+import (
+	"kleio/pkg/osv"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Local is the [VulnerabilitySource] of a directory of advisories (e.g., internal findings not disclosed publicly),
+// written as JSON or YAML files in the OSV format. The advisories are read the first time the directory is looked up
+type Local struct {
+	dir        string
+	once       sync.Once
+	advisories []osv.Vulnerability
+	err        error
+}
+
+// NewLocal returns the [Local] source of the advisories stored in a directory and its subdirectories
+func NewLocal(dir string) *Local {
+	return &Local{dir: dir}
+}
+
+// Name returns the name of the [Local] source
+func (l *Local) Name() string {
+	return "local"
+}
+
+// Lookup returns the advisories affecting a version of a package of an ecosystem, withdrawn ones included
+func (l *Local) Lookup(ecosystem string, name string, version string) ([]Vulnerability, error) {
+	l.once.Do(func() {
+		l.advisories, l.err = readAdvisories(l.dir)
+	})
+
+	if l.err != nil {
+		return nil, l.err
+	}
+
+	vulnerabilities := []Vulnerability{}
+
+	for _, advisory := range l.advisories {
+		if advisory.Affects(ecosystem, name, version) {
+			vulnerabilities = append(vulnerabilities, fromOSV(advisory, ecosystem, name, l.Name()))
+		}
+	}
+
+	return vulnerabilities, nil
+}
+
+// readAdvisories reads the `.json`, `.yaml`, and `.yml` advisories of a directory
+func readAdvisories(dir string) ([]osv.Vulnerability, error) {
+	advisories := []osv.Vulnerability{}
+
+	err := filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+
+		extension := strings.ToLower(filepath.Ext(path))
+
+		if extension != ".json" && extension != ".yaml" && extension != ".yml" {
+			return nil
+		}
+
+		content, err := os.ReadFile(path)
+
+		if err != nil {
+			return err
+		}
+
+		// YAML advisories are converted to JSON, so that they are decoded with the tags of the OSV format
+		if extension != ".json" {
+			var document any
+
+			if err = yaml.Unmarshal(content, &document); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+
+			if content, err = json.Marshal(document); err != nil {
+				return fmt.Errorf("%s: %w", path, err)
+			}
+		}
+
+		var advisory osv.Vulnerability
+
+		if err = json.Unmarshal(content, &advisory); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+
+		if advisory.Id == "" {
+			return fmt.Errorf("%s: advisory without id", path)
+		}
+
+		advisories = append(advisories, advisory)
+
+		return nil
+	})
+
+	return advisories, err
+}
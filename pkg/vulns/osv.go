@@ -0,0 +1,36 @@
+package vulns
+
+import "kleio/pkg/osv"
+
+// OSV is the [VulnerabilitySource] of the offline copy of the OSV database
+type OSV struct {
+	index *osv.Index
+}
+
+// NewOSV returns the [OSV] source of an offline copy of the OSV database
+func NewOSV(index *osv.Index) *OSV {
+	return &OSV{index: index}
+}
+
+// Name returns the name of the [OSV] source
+func (o *OSV) Name() string {
+	return "osv"
+}
+
+// Lookup returns the vulnerabilities affecting a version of a package of an ecosystem, withdrawn ones included.
+// Ecosystems that were not downloaded have no vulnerabilities
+func (o *OSV) Lookup(ecosystem string, name string, version string) ([]Vulnerability, error) {
+	found, err := o.index.Lookup(ecosystem, name, version)
+
+	if err != nil {
+		return nil, err
+	}
+
+	vulnerabilities := []Vulnerability{}
+
+	for _, vulnerability := range found {
+		vulnerabilities = append(vulnerabilities, fromOSV(vulnerability, ecosystem, name, o.Name()))
+	}
+
+	return vulnerabilities, nil
+}
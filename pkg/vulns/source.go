@@ -0,0 +1,238 @@
+package vulns
+
+import (
+	"kleio/pkg/config"
+	"kleio/pkg/osv"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// A Vulnerability is a vulnerability retrieved from one or more sources, in the shape shared by all of them. Its
+// affected ranges and fixed versions are the ones of the package it was looked up for
+type Vulnerability struct {
+	Id        string
+	Aliases   []string
+	Summary   string
+	CWEs      []string
+	CVSS      float64
+	Severity  string
+	Published time.Time
+	Modified  time.Time
+	Withdrawn time.Time
+	Affected  []string
+	Fixed     []string
+	Sources   []string
+}
+
+// CVE returns the CVE identifier of the [Vulnerability] struct, or the empty string if it has none
+func (v *Vulnerability) CVE() string {
+	for _, id := range append([]string{v.Id}, v.Aliases...) {
+		if strings.HasPrefix(id, "CVE-") {
+			return id
+		}
+	}
+
+	return ""
+}
+
+// ids returns the identifier and the aliases of the [Vulnerability] struct
+func (v *Vulnerability) ids() []string {
+	return append([]string{v.Id}, v.Aliases...)
+}
+
+// IsWithdrawn returns whether the [Vulnerability] struct was withdrawn
+func (v *Vulnerability) IsWithdrawn() bool {
+	return !v.Withdrawn.IsZero()
+}
+
+// fromOSV converts an entry of the OSV database (or a local advisory in the same format) retrieved from a source for a
+// package of an ecosystem
+func fromOSV(vulnerability osv.Vulnerability, ecosystem string, name string, source string) Vulnerability {
+	affected, fixed := vulnerability.Ranges(ecosystem, name)
+	converted := Vulnerability{
+		Id:        vulnerability.Id,
+		Aliases:   slices.Clone(vulnerability.Aliases),
+		Summary:   vulnerability.Summary,
+		CWEs:      slices.Clone(vulnerability.Database.CWEs),
+		CVSS:      vulnerability.CVSS(),
+		Published: vulnerability.Published,
+		Modified:  vulnerability.Modified,
+		Affected:  affected,
+		Fixed:     fixed,
+		Sources:   []string{source},
+	}
+
+	converted.Severity = severity(vulnerability.Database.Severity, converted.CVSS)
+
+	if vulnerability.IsWithdrawn() {
+		converted.Withdrawn = *vulnerability.Withdrawn
+	}
+
+	return converted
+}
+
+// severity returns the qualitative severity of a vulnerability (`LOW`, `MEDIUM`, `HIGH`, or `CRITICAL`), given by its
+// source or derived from its CVSS score
+func severity(label string, cvss float64) string {
+	switch label = strings.ToUpper(label); {
+	case label == "MODERATE":
+		return "MEDIUM"
+	case label != "":
+		return label
+	case cvss >= 9:
+		return "CRITICAL"
+	case cvss >= 7:
+		return "HIGH"
+	case cvss >= 4:
+		return "MEDIUM"
+	case cvss > 0:
+		return "LOW"
+	}
+
+	return ""
+}
+
+// A VulnerabilitySource is a database of vulnerabilities, which can be queried for the vulnerabilities affecting a
+// version of a package of an OSV ecosystem (see [osv.EcosystemNpm] and the like). Sources that do not cover an
+// ecosystem return no vulnerabilities
+type VulnerabilitySource interface {
+	Name() string
+	Lookup(ecosystem string, name string, version string) ([]Vulnerability, error)
+}
+
+// Sources is a list of [VulnerabilitySource], ordered by precedence
+type Sources []VulnerabilitySource
+
+// New returns the sources of vulnerabilities enabled in the configuration
+func New(cfg *config.Config) Sources {
+	sources := Sources{}
+
+	for _, name := range cfg.Vulns.Sources {
+		switch name {
+		case config.VulnSourceOSV:
+			sources = append(sources, NewOSV(osv.Open(cfg.OSV.Dir)))
+		case config.VulnSourceGHSA:
+			sources = append(sources, NewGHSA(cfg.GitHub.GetVulnToken()))
+		case config.VulnSourceLocal:
+			sources = append(sources, NewLocal(cfg.Vulns.AdvisoriesDir))
+		}
+	}
+
+	return sources
+}
+
+// Lookup returns the vulnerabilities affecting a version of a package of an ecosystem, retrieved from all the sources
+// and merged by alias. The vulnerabilities of the sources that succeeded are returned even if others failed
+func (s Sources) Lookup(ecosystem string, name string, version string) ([]Vulnerability, error) {
+	var found [][]Vulnerability
+	var errs []error
+
+	for _, source := range s {
+		vulnerabilities, err := source.Lookup(ecosystem, name, version)
+
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source.Name(), err))
+		}
+
+		found = append(found, vulnerabilities)
+	}
+
+	return Merge(found...), errors.Join(errs...)
+}
+
+// Merge merges the vulnerabilities sharing an identifier or an alias (e.g., a GHSA advisory and the CVE it refers to).
+// The identifier, summary, score, and withdrawal of a merged vulnerability are the ones of its first occurrence (and
+// thus of the source with the highest precedence), while its aliases, CWEs, ranges, fixed versions, and sources are the
+// union of the ones of all its occurrences
+func Merge(found ...[]Vulnerability) []Vulnerability {
+	merged := []Vulnerability{}
+
+	for _, vulnerabilities := range found {
+		for _, vulnerability := range vulnerabilities {
+			target := -1
+
+			// An occurrence may bridge previously distinct vulnerabilities, which are then merged together
+			for i := 0; i < len(merged); i++ {
+				if !slices.ContainsFunc(merged[i].ids(), func(id string) bool {
+					return slices.Contains(vulnerability.ids(), id)
+				}) {
+					continue
+				}
+
+				if target == -1 {
+					target = i
+					continue
+				}
+
+				merged[target] = combine(merged[target], merged[i])
+				merged = slices.Delete(merged, i, i+1)
+				i--
+			}
+
+			if target == -1 {
+				merged = append(merged, combine(Vulnerability{}, vulnerability))
+			} else {
+				merged[target] = combine(merged[target], vulnerability)
+			}
+		}
+	}
+
+	return merged
+}
+
+// combine adds the information of other to the one of a [Vulnerability] struct, which takes precedence
+func combine(base Vulnerability, other Vulnerability) Vulnerability {
+	// Only the first occurrence decides whether the vulnerability was withdrawn
+	if base.Id == "" {
+		base.Id = other.Id
+		base.Withdrawn = other.Withdrawn
+	}
+
+	if base.Summary == "" {
+		base.Summary = other.Summary
+	}
+
+	if base.CVSS == 0 {
+		base.CVSS = other.CVSS
+	}
+
+	if base.Severity == "" {
+		base.Severity = other.Severity
+	}
+
+	if base.Published.IsZero() || (!other.Published.IsZero() && other.Published.Before(base.Published)) {
+		base.Published = other.Published
+	}
+
+	if other.Modified.After(base.Modified) {
+		base.Modified = other.Modified
+	}
+
+	base.Aliases = union(base.Aliases, other.ids())
+	base.Aliases = slices.DeleteFunc(base.Aliases, func(alias string) bool {
+		return alias == base.Id
+	})
+
+	base.CWEs = union(base.CWEs, other.CWEs)
+	base.Affected = union(base.Affected, other.Affected)
+	base.Fixed = union(base.Fixed, other.Fixed)
+	base.Sources = union(base.Sources, other.Sources)
+
+	return base
+}
+
+// union returns the elements of a followed by the ones of b not in a, without duplicates or empty strings
+func union(a []string, b []string) []string {
+	result := []string{}
+
+	for _, element := range append(slices.Clone(a), b...) {
+		if element != "" && !slices.Contains(result, element) {
+			result = append(result, element)
+		}
+	}
+
+	return result
+}